@@ -0,0 +1,67 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Gunyoung-Kim/blockchain/db"
+)
+
+//ErrBrokenChain is error returned when blocks of storage don't make chain from checkpoint to genesis
+var ErrBrokenChain = errors.New("Blocks don't make chain from checkpoint to genesis")
+
+//ErrCorrupted is error returned when transactions of storage don't match its indexes
+var ErrCorrupted = errors.New("Transactions don't match indexes of storage")
+
+//Backup write consistent copy of storage of blockChain to w
+func Backup(w io.Writer) (int64, error) {
+	return store().Backup(w)
+}
+
+//ValidateStore check s is storage of blockChain which this binary can use
+//it checks schema version and audits s like Verify: hash, proof of work and link of each block from checkpoint to genesis,
+//then replayed transactions against utxoIndex, tokenIndex and txIndex
+//transactions of storage made before latest schema are not replayed, because migrations rebuild its indexes from blocks
+func ValidateStore(s db.Store) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", db.ErrInvalidSnapshot, r)
+		}
+	}()
+
+	if s.SchemaVersion() > db.LatestSchemaVersion() {
+		return db.ErrSchemaTooNew
+	}
+	checkPoint := s.CheckPoint()
+	if checkPoint == nil {
+		return db.ErrInvalidSnapshot
+	}
+	chain := &blockChain{}
+	chain.restoreFromBytes(checkPoint)
+
+	height := chain.Height
+	for hash := chain.NewestHash; hash != ""; height-- {
+		block, err := findBlock(s, hash)
+		if err != nil || block.Height != height {
+			return ErrBrokenChain
+		}
+		if err := checkHeader(block); err != nil {
+			return err
+		}
+		hash = block.PrevHash
+	}
+	if height != 0 {
+		return ErrBrokenChain
+	}
+
+	if s.SchemaVersion() < db.LatestSchemaVersion() {
+		return nil
+	}
+	report := verifyStore(s, chain)
+	if len(report.Findings) > 0 {
+		finding := report.Findings[0]
+		return fmt.Errorf("%w: %s at height %d, %d problems in total", ErrCorrupted, finding.Problem, finding.Height, len(report.Findings))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+func TestValidateStoreReplaysTransactions(t *testing.T) {
+	chain := BlockChain()
+	if chain.Height == 0 {
+		chain.AddBlock()
+	}
+	if err := ValidateStore(store()); err != nil {
+		t.Fatalf("ValidateStore of intact storage = %v", err)
+	}
+
+	// backup whose utxoIndex pays output which no block has
+	forged := &unspent{TxID: "forged", Index: 0, TxOut: &TxOut{Amount: 1000}}
+	save := func(update func(batch db.Batch)) {
+		utils.HandleError(store().Update(func(batch db.Batch) error {
+			update(batch)
+			return nil
+		}))
+	}
+	save(func(batch db.Batch) {
+		batch.SaveIndex(utxoIndex, outPoint(forged.TxID, forged.Index), utils.ToBytes(forged))
+	})
+	defer save(func(batch db.Batch) { batch.DeleteIndex(utxoIndex, outPoint(forged.TxID, forged.Index)) })
+	if err := ValidateStore(store()); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("ValidateStore with forged unspent output = %v, want %v", err, ErrCorrupted)
+	}
+}
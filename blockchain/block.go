@@ -29,15 +29,16 @@ func (b *Block) restoreFromBytes(data []byte) {
 	utils.FromBytes(b, data)
 }
 
-func (b *Block) persist() {
-	db.SaveBlock(b.Hash, utils.ToBytes(b))
-}
-
 // ----------- function for Block ----------------------------
 
 //FindBlock find block from DB by Hash of Block
 func FindBlock(hash string) (*Block, error) {
-	blockBytes := db.Block(hash)
+	return findBlock(store(), hash)
+}
+
+//findBlock find block from s by Hash of Block
+func findBlock(s db.Store, hash string) (*Block, error) {
+	blockBytes := s.Block(hash)
 	if blockBytes == nil {
 		return nil, ErrNotFound
 	}
@@ -46,10 +47,24 @@ func FindBlock(hash string) (*Block, error) {
 	return block, nil
 }
 
-func (b *Block) mine() {
+//calculateHash return hash of block which is calculated while mining
+//hash is calculated before Hash and Transactions are filled
+func (b *Block) calculateHash() string {
+	header := *b
+	header.Hash = ""
+	header.Transactions = nil
+	return utils.Hash(&header)
+}
+
+//mine find nonce which makes hash of block start with zeros as many as difficulty
+//timestamp of block is not less than minTimestamp
+func (b *Block) mine(minTimestamp int) {
 	target := strings.Repeat("0", b.Difficulty)
 	for {
 		b.Timestamp = int(time.Now().Unix())
+		if b.Timestamp < minTimestamp {
+			b.Timestamp = minTimestamp
+		}
 		hash := utils.Hash(b)
 		if strings.HasPrefix(hash, target) {
 			b.Hash = hash
@@ -69,8 +84,11 @@ func createBlock(prevHash string, height, diff int) *Block {
 		Difficulty: diff,
 		Nonce:      0,
 	}
-	block.mine()
-	block.Transactions = Mempool().txToConfirm()
-	block.persist()
+	minTimestamp := 0
+	if timestamps := pastTimestamps(prevHash); len(timestamps) > 0 {
+		minTimestamp = medianTime(timestamps) + 1
+	}
+	block.mine(minTimestamp)
+	block.Transactions = Mempool().txToConfirm(height)
 	return &block
 }
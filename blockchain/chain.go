@@ -2,11 +2,14 @@ package blockchain
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"sync"
 
 	"github.com/Gunyoung-Kim/blockchain/db"
 	"github.com/Gunyoung-Kim/blockchain/utils"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
 )
 
 const (
@@ -26,6 +29,29 @@ type blockChain struct {
 var b *blockChain // variable for singleton pattern of blockChain
 var once sync.Once
 
+var storage db.Store // storage where blockChain is persisted, it is db.Default() unless UseStore is called
+
+//UseStore inject storage where blockChain is persisted and upgrade its schema
+//it must be called before BlockChain is used
+//it returns error if schema of storage can't be upgraded
+func UseStore(s db.Store) error {
+	if err := db.Migrate(s); err != nil {
+		return err
+	}
+	storage = s
+	return nil
+}
+
+//store return storage of blockChain
+func store() db.Store {
+	if storage == nil {
+		s, err := db.Default()
+		utils.HandleError(err)
+		utils.HandleError(UseStore(s))
+	}
+	return storage
+}
+
 //------------ receiver function for blockChain ------------------
 
 func (b *blockChain) restoreFromBytes(data []byte) {
@@ -39,36 +65,59 @@ func (b *blockChain) AddBlock() *Block {
 	b.NewestHash = block.Hash
 	b.Height = block.Height
 	b.CurrentDifficulty = block.Difficulty
-	persistBlockChain(b)
+	connectBlock(b, block)
 	return block
 }
 
 // Replace blocks of blockchain and reflect to DB
-func (b *blockChain) Replace(blocks []*Block) {
+// blocks which go over limits of block or don't make valid chain from genesis are rejected with error
+func (b *blockChain) Replace(blocks []*Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	if err := validateBlocks(blocks); err != nil {
+		return err
+	}
+	if err := validateChain(blocks); err != nil {
+		return err
+	}
+
 	b.m.Lock()
 	defer b.m.Unlock()
 	b.CurrentDifficulty = blocks[0].Difficulty
 	b.Height = len(blocks)
 	b.NewestHash = blocks[0].Hash
-	persistBlockChain(b)
 
-	for _, block := range blocks {
-		block.persist()
-	}
+	utils.HandleError(store().Update(func(batch db.Batch) error {
+		for _, block := range blocks {
+			batch.SaveBlock(block.Hash, utils.ToBytes(block))
+		}
+		batch.SaveCheckPoint(utils.ToBytes(b))
+		rebuildIndexes(batch, blocks)
+		batch.DeleteIndex(pruneIndex, prunedHeightKey)
+		return nil
+	}))
+	pruneBlocks(b)
+	return nil
 }
 
-func (b *blockChain) AddPeerBlock(newBlock *Block) {
+//AddPeerBlock add block from peer on top of blockchain
+//block which can't be connected on top of blockchain is rejected with error
+func (b *blockChain) AddPeerBlock(newBlock *Block) error {
 	b.m.Lock()
 	m.m.Lock()
 	defer b.m.Unlock()
 	defer m.m.Unlock()
 
+	if err := validateBlock(b, newBlock); err != nil {
+		return err
+	}
+
 	b.Height++
 	b.CurrentDifficulty = newBlock.Difficulty
 	b.NewestHash = newBlock.Hash
 
-	persistBlockChain(b)
-	newBlock.persist()
+	connectBlock(b, newBlock)
 
 	for _, tx := range newBlock.Transactions {
 		_, ok := m.Txs[tx.ID]
@@ -76,6 +125,7 @@ func (b *blockChain) AddPeerBlock(newBlock *Block) {
 			delete(m.Txs, tx.ID)
 		}
 	}
+	return nil
 }
 
 //------------ function for blockChain ------------------
@@ -83,23 +133,51 @@ func (b *blockChain) AddPeerBlock(newBlock *Block) {
 // BlockChain get blockChain
 // This function is for singleton pattern of blockChain
 func BlockChain() *blockChain {
+	return openBlockChain(true)
+}
+
+//ErrEmptyChain is error returned when blockchain is asked for but DB has no block yet
+var ErrEmptyChain = errors.New("Blockchain has no block yet")
+
+//ExistingBlockChain get blockChain restored from DB without creating genesis block
+//commands which only read blockchain use it, so they don't need wallet to mine genesis block
+func ExistingBlockChain() (*blockChain, error) {
+	chain := openBlockChain(false)
+	if chain.NewestHash == "" {
+		return nil, ErrEmptyChain
+	}
+	return chain, nil
+}
+
+//openBlockChain restore blockChain from checkpoint in DB
+//if there is no checkpoint, it creates genesis block only when withGenesis is true
+func openBlockChain(withGenesis bool) *blockChain {
 	once.Do(func() {
 		b = &blockChain{
 			Height: 0,
 		}
-		checkPoint := db.CheckPoint()
-		if checkPoint == nil {
-			b.AddBlock()
-		} else {
+		checkPoint := store().CheckPoint()
+		if checkPoint != nil {
 			b.restoreFromBytes(checkPoint)
+		} else if withGenesis {
+			b.AddBlock()
 		}
 	})
 	return b
 }
 
-//persistBlockChain save checkpoint of blockchain to DB
-func persistBlockChain(b *blockChain) {
-	db.SaveCheckPoint(utils.ToBytes(b))
+//connectBlock save block on top of blockchain, checkpoint of blockchain and indexes updated by block
+//they are committed in one batch, so checkpoint never points at missing block
+//then watchers are notified of transactions of block and bodies of old blocks are pruned if pruning is enabled
+func connectBlock(b *blockChain, block *Block) {
+	utils.HandleError(store().Update(func(batch db.Batch) error {
+		batch.SaveBlock(block.Hash, utils.ToBytes(block))
+		batch.SaveCheckPoint(utils.ToBytes(b))
+		indexBlock(batch, block)
+		return nil
+	}))
+	notifyWatchers(block.Transactions, block, b)
+	pruneBlocks(b)
 }
 
 //Blocks return all pointer of Blocks from DB
@@ -140,6 +218,21 @@ func FindTransaction(b *blockChain, targetID string) *Tx {
 	return nil
 }
 
+//FindTxOut return output of transaction whose ID is txID at index
+//it returns nil if there is no such output in blockChain
+func FindTxOut(b *blockChain, txID string, index int) *TxOut {
+	tx := FindTransaction(b, txID)
+	if tx == nil || index < 0 || index >= len(tx.TxOuts) {
+		return nil
+	}
+	return tx.TxOuts[index]
+}
+
+//outPoint return key which identifies output of transaction
+func outPoint(txID string, index int) string {
+	return fmt.Sprintf("%s:%d", txID, index)
+}
+
 //recalculateDifficulty recalculate difficulty of creating new block
 func recalculateDifficulty(b *blockChain) int {
 	allBlocks := Blocks(b)
@@ -169,39 +262,35 @@ func getDifficulty(b *blockChain) int {
 	}
 }
 
+//Status write status of blockChain with its pruning status as JSON
 func Status(b *blockChain, rw http.ResponseWriter) {
 	b.m.Lock()
 	defer b.m.Unlock()
 
-	json.NewEncoder(rw).Encode(b)
+	json.NewEncoder(rw).Encode(struct {
+		*blockChain
+		PruneDepth   int `json:"pruneDepth"`
+		PrunedHeight int `json:"prunedHeight"`
+	}{b, PruneDepth, PrunedHeight()})
 }
 
-//UTxOutsByAddress return slice of UTxOut whose owner is given address
-//exploring blockchain from newestblock to oldestblock if blocks transaction contain TxIn of address then add key,value to map
-//if Tx contains txOut of address and its Id is not in map then add that txOut to UnusedTxOuts
+//UTxOutsByAddress return slice of UTxOut of coin whose owner is given address
 func UTxOutsByAddress(address string, b *blockChain) []*UTxOut {
-	var uTxOuts []*UTxOut
-	creatorTxs := make(map[string]bool)
-	for _, block := range Blocks(b) {
-		for _, tx := range block.Transactions {
-			for _, input := range tx.TxIns {
-				if input.Signature == "COINBASE" {
-					break
-				}
-				if FindTransaction(b, input.TxID).TxOuts[input.Index].Address == address {
-					creatorTxs[input.TxID] = true
-				}
-			}
+	return TokenUTxOutsByAddress(address, "", b)
+}
 
-			for index, output := range tx.TxOuts {
-				if output.Address == address {
-					if _, ok := creatorTxs[tx.ID]; !ok {
-						uTxOut := &UTxOut{tx.ID, index, output.Amount}
-						if !isOnMempool(uTxOut) {
-							uTxOuts = append(uTxOuts, uTxOut)
-						}
-					}
-				}
+//TokenUTxOutsByAddress return slice of UTxOut of token whose owner is given address
+//it reads unspent outputs from utxoIndex and skips ones which are used for input of transaction in mempool
+//outputs paid to legacy address of same public key belong to address too
+func TokenUTxOutsByAddress(address, token string, b *blockChain) []*UTxOut {
+	var uTxOuts []*UTxOut
+	address = wallet.NormalizeAddress(address)
+	for _, entry := range allUnspent() {
+		output := entry.TxOut
+		if wallet.NormalizeAddress(output.Address) == address && output.Token == token {
+			uTxOut := &UTxOut{entry.TxID, entry.Index, output.Amount, output.Token}
+			if !isOnMempool(uTxOut) {
+				uTxOuts = append(uTxOuts, uTxOut)
 			}
 		}
 	}
@@ -209,13 +298,33 @@ func UTxOutsByAddress(address string, b *blockChain) []*UTxOut {
 	return uTxOuts
 }
 
-//BalanceByAddress return balance of address which is calculated by slice of unused Txouts
+//BalanceByAddress return balance of coin of address which is calculated by slice of unused Txouts
 func BalanceByAddress(address string, b *blockChain) int {
+	return TokenBalanceByAddress(address, "", b)
+}
+
+//TokenBalanceByAddress return balance of token of address which is calculated by slice of unused Txouts
+func TokenBalanceByAddress(address, token string, b *blockChain) int {
 	var amount int
-	txOuts := UTxOutsByAddress(address, b)
+	txOuts := TokenUTxOutsByAddress(address, token, b)
 
 	for _, txOut := range txOuts {
 		amount += txOut.Amount
 	}
 	return amount
 }
+
+//UsedAddresses return set of addresses which have ever received output in blockChain
+//outputs of pruned blocks are known only if they are unspent
+func UsedAddresses(b *blockChain) map[string]bool {
+	used := make(map[string]bool)
+	for _, tx := range Transactions(b) {
+		for _, txOut := range tx.TxOuts {
+			used[wallet.NormalizeAddress(txOut.Address)] = true
+		}
+	}
+	for _, entry := range allUnspent() {
+		used[wallet.NormalizeAddress(entry.TxOut.Address)] = true
+	}
+	return used
+}
@@ -0,0 +1,67 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+func TestBlockChainInMemory(t *testing.T) {
+	chain := BlockChain()
+	height := chain.Height
+	balance := BalanceByAddress(wallet.Wallet().Address, chain)
+
+	block := chain.AddBlock()
+	if chain.Height != height+1 || chain.NewestHash != block.Hash {
+		t.Fatalf("tip is %s at height %d, want %s at height %d", chain.NewestHash, chain.Height, block.Hash, height+1)
+	}
+	if got := BalanceByAddress(wallet.Wallet().Address, chain); got != balance+minerReward {
+		t.Errorf("balance is %d, want %d", got, balance+minerReward)
+	}
+	if found, err := FindBlock(block.Hash); err != nil || found.Hash != block.Hash {
+		t.Errorf("FindBlock(%s) = %v, %v", block.Hash, found, err)
+	}
+	if report := Verify(chain); !report.OK {
+		t.Errorf("Verify found problems: %v", report.Findings)
+	}
+}
+
+func TestExistingBlockChain(t *testing.T) {
+	BlockChain()
+	chain, err := ExistingBlockChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.NewestHash == "" {
+		t.Error("ExistingBlockChain returned chain without tip")
+	}
+}
+
+func TestReplaceValidatesChain(t *testing.T) {
+	chain := BlockChain()
+	chain.AddBlock()
+	chain.AddBlock()
+
+	blocks := Blocks(chain)
+	if err := chain.Replace(blocks); err != nil {
+		t.Fatalf("Replace with own chain: %v", err)
+	}
+
+	// coinbase which pays more than reward, hash of block doesn't cover its transactions
+	tampered := Blocks(chain)
+	coinbase := tampered[1].Transactions[len(tampered[1].Transactions)-1]
+	coinbase.TxOuts = append(coinbase.TxOuts, coinbase.TxOuts[0])
+	if err := chain.Replace(tampered); !errors.Is(err, ErrInvalidCoinbase) {
+		t.Fatalf("Replace with inflated coinbase = %v, want %v", err, ErrInvalidCoinbase)
+	}
+
+	unlinked := Blocks(chain)
+	unlinked[0].Height++
+	if err := chain.Replace(unlinked); err == nil {
+		t.Fatal("Replace accepted chain with wrong height")
+	}
+	if chain.NewestHash != blocks[0].Hash || chain.Height != blocks[0].Height {
+		t.Errorf("tip is %s at height %d after rejected chains, want %s at height %d", chain.NewestHash, chain.Height, blocks[0].Hash, blocks[0].Height)
+	}
+}
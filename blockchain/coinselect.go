@@ -0,0 +1,183 @@
+package blockchain
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Names of coin selection strategies which can be chosen per transaction
+const (
+	FirstFit      string = "first"    // unspent outputs in order they are found, default strategy
+	LargestFirst  string = "largest"  // fewest inputs
+	SmallestFirst string = "smallest" // consolidate small outputs
+	BranchBound   string = "bnb"      // exact amount without change if possible, otherwise largest first
+	RandomOrder   string = "random"   // random outputs, so addresses are not linked in predictable way
+
+	bnbMaxTries int = 100000 // number of branches which branch and bound explores before giving up
+)
+
+var (
+	//ErrUnknownStrategy is error returned when coin selection strategy of name doesn't exist
+	ErrUnknownStrategy = errors.New("Unknown coin selection strategy")
+	//ErrNotSpendable is error returned when manually selected output can't be spent by wallet
+	ErrNotSpendable = errors.New("Selected output is not spendable by wallet")
+)
+
+//OutPoint is location of output which is spent by input
+type OutPoint struct {
+	TxID  string `json:"txID"`
+	Index int    `json:"index"`
+}
+
+//CoinSelector choose unspent outputs which pay amount from candidates
+//it returns ErrorNoMoney if it can't pay amount
+type CoinSelector interface {
+	Select(candidates []*UTxOut, amount int) ([]*UTxOut, error)
+}
+
+var coinSelectors = map[string]CoinSelector{
+	FirstFit:      firstFit{},
+	LargestFirst:  largestFirst{},
+	SmallestFirst: smallestFirst{},
+	BranchBound:   branchAndBound{},
+	RandomOrder:   randomOrder{},
+}
+
+//NewCoinSelector return CoinSelector which spends inputs if they are given, otherwise one of strategy
+//empty strategy means FirstFit
+func NewCoinSelector(strategy string, inputs []OutPoint) (CoinSelector, error) {
+	if len(inputs) > 0 {
+		return manual(inputs), nil
+	}
+	if strategy == "" {
+		strategy = FirstFit
+	}
+	selector, ok := coinSelectors[strategy]
+	if !ok {
+		return nil, ErrUnknownStrategy
+	}
+	return selector, nil
+}
+
+// collect take outputs in order until their total covers amount
+func collect(ordered []*UTxOut, amount int) ([]*UTxOut, error) {
+	var selected []*UTxOut
+	total := 0
+	for _, uTxOut := range ordered {
+		if total >= amount {
+			break
+		}
+		selected = append(selected, uTxOut)
+		total += uTxOut.Amount
+	}
+	if total < amount {
+		return nil, ErrorNoMoney
+	}
+	return selected, nil
+}
+
+// sortedByAmount return copy of candidates ordered by amount, largest first if descending
+func sortedByAmount(candidates []*UTxOut, descending bool) []*UTxOut {
+	sorted := append([]*UTxOut{}, candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if descending {
+			return sorted[i].Amount > sorted[j].Amount
+		}
+		return sorted[i].Amount < sorted[j].Amount
+	})
+	return sorted
+}
+
+type firstFit struct{}
+
+func (firstFit) Select(candidates []*UTxOut, amount int) ([]*UTxOut, error) {
+	return collect(candidates, amount)
+}
+
+type largestFirst struct{}
+
+func (largestFirst) Select(candidates []*UTxOut, amount int) ([]*UTxOut, error) {
+	return collect(sortedByAmount(candidates, true), amount)
+}
+
+type smallestFirst struct{}
+
+func (smallestFirst) Select(candidates []*UTxOut, amount int) ([]*UTxOut, error) {
+	return collect(sortedByAmount(candidates, false), amount)
+}
+
+type randomOrder struct{}
+
+func (randomOrder) Select(candidates []*UTxOut, amount int) ([]*UTxOut, error) {
+	shuffled := append([]*UTxOut{}, candidates...)
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	r.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return collect(shuffled, amount)
+}
+
+// branchAndBound search depth first for outputs whose total is exactly amount, so no change is needed
+// branch is cut when it goes over amount or rest of outputs can't reach it
+type branchAndBound struct{}
+
+func (branchAndBound) Select(candidates []*UTxOut, amount int) ([]*UTxOut, error) {
+	sorted := sortedByAmount(candidates, true)
+	rest := make([]int, len(sorted)+1) // rest[i] is total of sorted[i:]
+	for i := len(sorted) - 1; i >= 0; i-- {
+		rest[i] = rest[i+1] + sorted[i].Amount
+	}
+	if rest[0] < amount {
+		return nil, ErrorNoMoney
+	}
+
+	var best []*UTxOut
+	tries := 0
+	var search func(i, total int, picked []*UTxOut) bool
+	search = func(i, total int, picked []*UTxOut) bool {
+		if total == amount {
+			best = append([]*UTxOut{}, picked...)
+			return true
+		}
+		if i == len(sorted) || total+rest[i] < amount || tries >= bnbMaxTries {
+			return false
+		}
+		tries++
+		if total+sorted[i].Amount <= amount && search(i+1, total+sorted[i].Amount, append(picked, sorted[i])) {
+			return true
+		}
+		return search(i+1, total, picked)
+	}
+	if search(0, 0, nil) {
+		return best, nil
+	}
+	return largestFirst{}.Select(candidates, amount)
+}
+
+// manual spend exactly outputs chosen by user, all of them must be candidates
+type manual []OutPoint
+
+func (m manual) Select(candidates []*UTxOut, amount int) ([]*UTxOut, error) {
+	spendable := make(map[string]*UTxOut)
+	for _, uTxOut := range candidates {
+		spendable[outPoint(uTxOut.TxID, uTxOut.Index)] = uTxOut
+	}
+	var selected []*UTxOut
+	total := 0
+	for _, point := range m {
+		key := outPoint(point.TxID, point.Index)
+		uTxOut, ok := spendable[key]
+		if !ok {
+			return nil, ErrNotSpendable
+		}
+		delete(spendable, key)
+		selected = append(selected, uTxOut)
+		total += uTxOut.Amount
+	}
+	if total < amount {
+		return nil, ErrorNoMoney
+	}
+	return selected, nil
+}
@@ -0,0 +1,95 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// uTxOuts make unspent outputs of amounts, each in its own transaction
+func uTxOuts(amounts ...int) []*UTxOut {
+	var outs []*UTxOut
+	for i, amount := range amounts {
+		outs = append(outs, &UTxOut{TxID: fmt.Sprintf("tx%d", i), Amount: amount})
+	}
+	return outs
+}
+
+func amountsOf(outs []*UTxOut) []int {
+	amounts := []int{}
+	for _, out := range outs {
+		amounts = append(amounts, out.Amount)
+	}
+	return amounts
+}
+
+func TestBranchAndBound(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []int
+		amount     int
+		want       []int
+		err        error
+	}{
+		{"exact output", []int{5, 3, 2}, 3, []int{3}, nil},
+		{"exact combination", []int{8, 5, 4, 3}, 7, []int{4, 3}, nil},
+		{"no change over fewer inputs", []int{9, 6, 4}, 10, []int{6, 4}, nil},
+		{"all outputs", []int{1, 2, 3}, 6, []int{3, 2, 1}, nil},
+		{"zero amount", []int{1, 2}, 0, []int{}, nil},
+		{"largest first without exact match", []int{2, 7, 5}, 4, []int{7}, nil},
+		{"largest first over several outputs", []int{6, 9}, 10, []int{9, 6}, nil},
+		{"not enough money", []int{3, 2}, 6, nil, ErrorNoMoney},
+		{"no candidates", nil, 1, nil, ErrorNoMoney},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			candidates := uTxOuts(test.candidates...)
+			selected, err := branchAndBound{}.Select(candidates, test.amount)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Select = %v, want %v", err, test.err)
+			}
+			if err == nil && !reflect.DeepEqual(amountsOf(selected), test.want) {
+				t.Errorf("Select = %v, want %v", amountsOf(selected), test.want)
+			}
+			if !reflect.DeepEqual(candidates, uTxOuts(test.candidates...)) {
+				t.Error("Select reordered candidates")
+			}
+		})
+	}
+}
+
+func TestBranchAndBoundGivesUp(t *testing.T) {
+	// odd amount is never exact sum of even outputs, but search can't prove it before bnbMaxTries
+	var amounts []int
+	for i := 0; i < 40; i++ {
+		amounts = append(amounts, 2)
+	}
+	selected, err := branchAndBound{}.Select(uTxOuts(amounts...), 41)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 21 {
+		t.Errorf("Select picked %d outputs, want 21 of largest first", len(selected))
+	}
+}
+
+func TestNewCoinSelector(t *testing.T) {
+	tests := []struct {
+		strategy string
+		inputs   []OutPoint
+		want     CoinSelector
+		err      error
+	}{
+		{"", nil, firstFit{}, nil},
+		{BranchBound, nil, branchAndBound{}, nil},
+		{"cheapest", nil, nil, ErrUnknownStrategy},
+		{"cheapest", []OutPoint{{"tx0", 0}}, manual{{"tx0", 0}}, nil},
+	}
+	for _, test := range tests {
+		selector, err := NewCoinSelector(test.strategy, test.inputs)
+		if !errors.Is(err, test.err) || !reflect.DeepEqual(selector, test.want) {
+			t.Errorf("NewCoinSelector(%q, %v) = %#v, %v, want %#v, %v", test.strategy, test.inputs, selector, err, test.want, test.err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/Gunyoung-Kim/blockchain/script"
+)
+
+const (
+	defaultMaxDataSize int = 80
+	minDataFee         int = 1
+)
+
+//MaxDataSize is maximum number of bytes which data output can carry
+var MaxDataSize = defaultMaxDataSize
+
+//ErrorDataTooLarge is error returned when data is bigger than MaxDataSize
+var ErrorDataTooLarge = errors.New("Data is too large")
+
+//ErrorInvalidData is error returned when data is not hexa-decimal string
+var ErrorInvalidData = errors.New("Data must be non-empty hexa-decimal string")
+
+//ErrorFeeTooLow is error returned when fee is lower than minimum fee
+var ErrorFeeTooLow = errors.New("Fee is too low")
+
+//Anchor is location of data output in blockChain
+type Anchor struct {
+	Data      string `json:"data"`
+	TxID      string `json:"txID"`
+	Index     int    `json:"index"`
+	BlockHash string `json:"blockHash"`
+	Height    int    `json:"height"`
+	Timestamp int    `json:"timestamp"`
+}
+
+//AddData add transaction which carries data(hex) in unspendable output from wallet of name from to mempool
+//transaction only pays fee, rest of inputs goes back to wallet as change, empty from means default wallet
+//data is stored in lowercase whatever case it is given in
+func (m *mempool) AddData(from, data string, fee int) (*Tx, error) {
+	bytes, err := hex.DecodeString(data)
+	if err != nil || len(bytes) == 0 {
+		return nil, ErrorInvalidData
+	}
+	if len(bytes) > MaxDataSize {
+		return nil, ErrorDataTooLarge
+	}
+	if fee < minDataFee {
+		return nil, ErrorFeeTooLow
+	}
+
+	output := &TxOut{"", 0, script.Data(hex.EncodeToString(bytes)), ""}
+	tx, err := makeTxWithOutput(from, "", fee, output, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.add(tx)
+	return tx, nil
+}
+
+//checkDataSize reject transaction whose data output carries more than MaxDataSize bytes
+//MaxDataSize is policy of node, so it applies to transactions node accepts and mines, not to blocks of others
+func checkDataSize(t *Tx) error {
+	for index, output := range t.TxOuts {
+		if script.IsData(output.Script) && (len(output.Script)-len(script.Data("")))/2 > MaxDataSize {
+			return rejectOutput(RejectDataSize, index, ErrorDataTooLarge)
+		}
+	}
+	return nil
+}
+
+//FindAnchors return all anchors in blockChain whose data is same as input data
+//data is hexa-decimal, so it is compared regardless of case
+func FindAnchors(b *blockChain, data string) []*Anchor {
+	data = strings.ToLower(data)
+	var anchors []*Anchor
+	for _, block := range Blocks(b) {
+		for _, tx := range block.Transactions {
+			for index, output := range tx.TxOuts {
+				if script.IsData(output.Script) && strings.ToLower(script.DataOf(output.Script)) == data {
+					anchors = append(anchors, &Anchor{
+						Data:      data,
+						TxID:      tx.ID,
+						Index:     index,
+						BlockHash: block.Hash,
+						Height:    block.Height,
+						Timestamp: block.Timestamp,
+					})
+				}
+			}
+		}
+	}
+	return anchors
+}
@@ -0,0 +1,60 @@
+package blockchain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Gunyoung-Kim/blockchain/script"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+func TestDataSizeIsCheckedOutsideAddData(t *testing.T) {
+	chain := BlockChain()
+	chain.AddBlock()
+
+	tx, err := Mempool().AddData(wallet.DefaultName, strings.Repeat("ab", 8), minDataFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyTx(tx); err != nil {
+		t.Fatalf("verifyTx of data within MaxDataSize = %v", err)
+	}
+
+	// node with smaller limit, like one started with -maxdata, receives same transaction
+	defer func(size int) { MaxDataSize = size }(MaxDataSize)
+	MaxDataSize = 4
+	var rejection *Rejection
+	if err := verifyTx(tx); !errors.As(err, &rejection) || rejection.Code != RejectDataSize {
+		t.Fatalf("verifyTx of data over MaxDataSize = %v, want rejection %s", err, RejectDataSize)
+	}
+	if rejection.Output == nil || !script.IsData(tx.TxOuts[*rejection.Output].Script) {
+		t.Errorf("rejection points at output %v, want data output", rejection.Output)
+	}
+	block := chain.AddBlock()
+	for _, confirmed := range block.Transactions {
+		if confirmed.ID == tx.ID {
+			t.Fatal("data over MaxDataSize is mined")
+		}
+	}
+}
+
+func TestFindAnchorsIgnoresCase(t *testing.T) {
+	chain := BlockChain()
+	if BalanceByAddress(wallet.Wallet().Address, chain) < minDataFee {
+		chain.AddBlock()
+	}
+	data := "C0FFEE" + wallet.NewPreimage()[:8]
+	tx, err := Mempool().AddData("", data, minDataFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.AddBlock()
+
+	for _, query := range []string{data, strings.ToLower(data)} {
+		anchors := FindAnchors(chain, query)
+		if len(anchors) != 1 || anchors[0].TxID != tx.ID {
+			t.Errorf("FindAnchors(%q) = %d anchors, want transaction %s", query, len(anchors), tx.ID)
+		}
+	}
+}
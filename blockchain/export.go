@@ -0,0 +1,171 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Export format is JSON Lines: every line is one Block encoded as JSON,
+// same as response of GET /blocks/{hash}, and lines are ordered from genesis to tip.
+// So the first line has no prevHash and every other line has prevHash of the line above it.
+
+var (
+	//ErrInvalidHash is error returned when hash of block doesn't match its content or difficulty
+	ErrInvalidHash = errors.New("Block hash is invalid")
+	//ErrNotConnected is error returned when block doesn't extend tip of blockchain
+	ErrNotConnected = errors.New("Block doesn't extend tip of blockchain")
+	//ErrInvalidCoinbase is error returned when block doesn't have one coinbase transaction with legal reward
+	ErrInvalidCoinbase = errors.New("Block has invalid coinbase transaction")
+	//ErrInvalidTx is error returned when block contains illegal transaction
+	ErrInvalidTx = errors.New("Block contains invalid transaction")
+)
+
+//Export write all blocks from genesis to tip to w in export format
+//progress is called after each block is written
+//it returns ErrPruned if bodies of blocks are pruned
+func Export(b *blockChain, w io.Writer, progress func(done, total int)) error {
+	if PrunedHeight() > 0 {
+		return ErrPruned
+	}
+	blocks := Blocks(b)
+	encoder := json.NewEncoder(w)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if err := encoder.Encode(blocks[i]); err != nil {
+			return err
+		}
+		progress(len(blocks)-i, len(blocks))
+	}
+	return nil
+}
+
+//Import read blocks in export format from r, validate each block and connect it on top of blockchain
+//blocks which are already in blockchain are skipped
+//progress is called after each block is connected or skipped
+func Import(r io.Reader, progress func(height int)) error {
+	chain := openBlockChain(false)
+	decoder := json.NewDecoder(r)
+	for {
+		block := &Block{}
+		err := decoder.Decode(block)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if block.Height <= chain.Height {
+			if _, err := FindBlock(block.Hash); err == nil {
+				progress(block.Height)
+				continue
+			}
+		}
+		if err := validateBlock(chain, block); err != nil {
+			return err
+		}
+
+		chain.m.Lock()
+		chain.Height = block.Height
+		chain.CurrentDifficulty = block.Difficulty
+		chain.NewestHash = block.Hash
+		connectBlock(chain, block)
+		chain.m.Unlock()
+		progress(block.Height)
+	}
+}
+
+//validateBlock check block can be connected on top of blockchain
+//it checks link to tip, height, hash, proof of work, limits, coinbase and transactions
+func validateBlock(b *blockChain, block *Block) error {
+	if err := checkLink(b, block); err != nil {
+		return err
+	}
+	if err := checkBlockLimits(block, pastTimestamps(block.PrevHash)); err != nil {
+		return err
+	}
+	return checkTransactions(block, indexView{})
+}
+
+//validateChain check blocks from peer make chain from genesis which can replace blockchain
+//blocks are ordered from newest to oldest like Blocks, their limits are checked by validateBlocks
+//each block is checked like validateBlock against state replayed from blocks before it
+func validateChain(blocks []*Block) error {
+	tip := &blockChain{}
+	view := newReplayView()
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		if err := checkLink(tip, block); err != nil {
+			return err
+		}
+		if err := checkTransactions(block, view); err != nil {
+			return err
+		}
+		view.connect(block)
+		tip.NewestHash = block.Hash
+		tip.Height = block.Height
+	}
+	return nil
+}
+
+//checkLink check block extends tip of b and its hash and proof of work
+func checkLink(b *blockChain, block *Block) error {
+	if block.PrevHash != b.NewestHash || block.Height != b.Height+1 {
+		return ErrNotConnected
+	}
+	return checkHeader(block)
+}
+
+//checkTransactions check coinbase of block and its transactions against view
+//transactions must not spend same output or issue same token twice in block
+//IDs of transactions must be unique in block and chain, otherwise outputs of one overwrite other in utxoIndex
+func checkTransactions(block *Block, view chainView) error {
+	fees := 0
+	var coinbase *Tx
+	ids := make(map[string]bool)
+	spent := make(map[string]bool)
+	tokens := make(map[string]bool)
+	for _, tx := range block.Transactions {
+		if ids[tx.ID] || view.known(tx.ID) {
+			return ErrInvalidTx
+		}
+		ids[tx.ID] = true
+		if len(tx.TxIns) == 1 && tx.TxIns[0].isCoinbase() {
+			if coinbase != nil || tx.ID != coinbaseID(tx, block.Height) {
+				return ErrInvalidCoinbase
+			}
+			coinbase = tx
+			continue
+		}
+		if verifyTxAt(tx, view, block.Height, block.Timestamp) != nil {
+			return ErrInvalidTx
+		}
+		for _, txIn := range tx.TxIns {
+			if spent[outPoint(txIn.TxID, txIn.Index)] {
+				return ErrInvalidTx
+			}
+			spent[outPoint(txIn.TxID, txIn.Index)] = true
+		}
+		if tx.Issuance != nil {
+			if tokens[tx.Issuance.TokenID()] {
+				return ErrInvalidTx
+			}
+			tokens[tx.Issuance.TokenID()] = true
+		}
+		fees += tx.fee(view)
+	}
+	if coinbase == nil {
+		return ErrInvalidCoinbase
+	}
+	reward := 0
+	for _, txOut := range coinbase.TxOuts {
+		if txOut.Token != "" || !txOut.matchesScript() {
+			return ErrInvalidCoinbase
+		}
+		reward += txOut.Amount
+	}
+	if reward > minerReward+fees {
+		return ErrInvalidCoinbase
+	}
+	return nil
+}
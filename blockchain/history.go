@@ -0,0 +1,258 @@
+package blockchain
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+// Directions of WalletTx
+const (
+	Incoming string = "incoming"
+	Outgoing string = "outgoing"
+	Self     string = "self" // every input and output belongs to addresses
+)
+
+//WalletTx is transaction which pays to or spends from addresses, seen from owner of them
+//Amount is change of coin balance of addresses, negative when they spend more than they receive
+//Tokens is change of balance of each token in same way
+//Counterparties are senders of incoming transaction, receivers of outgoing one
+//transaction in mempool has no confirmation and no block
+type WalletTx struct {
+	TxID           string         `json:"txID"`
+	Direction      string         `json:"direction"`
+	Coinbase       bool           `json:"coinbase,omitempty"`
+	Amount         int            `json:"amount"`
+	Tokens         map[string]int `json:"tokens,omitempty"`
+	Counterparties []string       `json:"counterparties"`
+	Confirmations  int            `json:"confirmations"`
+	Timestamp      int            `json:"timestamp"`
+	BlockHash      string         `json:"blockHash,omitempty"`
+	Label          string         `json:"label,omitempty"`
+}
+
+// add amount of token to change of balance
+func (w *WalletTx) add(token string, amount int) {
+	if token == "" {
+		w.Amount += amount
+		return
+	}
+	if w.Tokens == nil {
+		w.Tokens = make(map[string]int)
+	}
+	w.Tokens[token] += amount
+}
+
+//indexHistory add transactions of block to txIndex, addressIndex, spentIndex and tokenIndex
+//entries are only added, never deleted, so blocks can be indexed in any order
+func indexHistory(batch db.Batch, block *Block) {
+	for _, tx := range block.Transactions {
+		batch.SaveIndex(txIndex, tx.ID, []byte(block.Hash))
+		if tx.Issuance != nil {
+			batch.SaveIndex(tokenIndex, tx.Issuance.TokenID(), []byte(tx.ID))
+		}
+		for _, output := range tx.TxOuts {
+			if output.Address == "" {
+				continue
+			}
+			batch.SaveIndex(addressIndex, addressKey(output.Address, tx.ID), []byte(tx.ID))
+		}
+		for _, input := range tx.TxIns {
+			if input.isCoinbase() {
+				continue
+			}
+			batch.SaveIndex(spentIndex, outPoint(input.TxID, input.Index), []byte(tx.ID))
+		}
+	}
+}
+
+//clearHistory clear txIndex, addressIndex, spentIndex and tokenIndex
+func clearHistory(batch db.Batch) {
+	batch.ClearIndex(txIndex)
+	batch.ClearIndex(addressIndex)
+	batch.ClearIndex(spentIndex)
+	batch.ClearIndex(tokenIndex)
+}
+
+//addressKey return key of addressIndex, outputs paid to legacy address are indexed under normalized one
+func addressKey(address, txID string) string {
+	return wallet.NormalizeAddress(address) + "/" + txID
+}
+
+//txIDsByAddress return IDs of transactions which pay to address
+//keys of addressIndex start with address, so only entries of address are read
+func txIDsByAddress(address string) []string {
+	var txIDs []string
+	prefix := addressKey(address, "")
+	for key := range store().IndexPrefix(addressIndex, prefix) {
+		txIDs = append(txIDs, strings.TrimPrefix(key, prefix))
+	}
+	return txIDs
+}
+
+//locateTx return transaction of ID and block which includes it
+//it returns nil if transaction is unknown or body of its block is pruned
+func locateTx(txID string) (*Tx, *Block) {
+	hash := store().Index(txIndex, txID)
+	if hash == nil {
+		return nil, nil
+	}
+	block, err := FindBlock(string(hash))
+	if err != nil {
+		return nil, nil
+	}
+	for _, tx := range block.Transactions {
+		if tx.ID == txID {
+			return tx, block
+		}
+	}
+	return nil, nil
+}
+
+//spenderOf return ID of transaction in blockChain which spends output of transaction txID at index, empty if it is unspent
+func spenderOf(txID string, index int) string {
+	return string(store().Index(spentIndex, outPoint(txID, index)))
+}
+
+//previousTxOut return output which input spends, nil if it can't be found
+func previousTxOut(input *TxIn) *TxOut {
+	if prevTx, _ := locateTx(input.TxID); prevTx != nil && input.Index >= 0 && input.Index < len(prevTx.TxOuts) {
+		return prevTx.TxOuts[input.Index]
+	}
+	return unspentTxOut(input.TxID, input.Index)
+}
+
+//WalletTxs return transactions which pay to or spend from addresses, unconfirmed ones first then newest first
+//transactions paying to addresses are found by addressIndex and ones spending their outputs by spentIndex
+//transactions in pruned blocks are not included because their bodies are deleted
+func WalletTxs(addresses []string, b *blockChain) []*WalletTx {
+	mine := make(map[string]bool)
+	for _, address := range addresses {
+		mine[wallet.NormalizeAddress(address)] = true
+	}
+
+	var walletTxs []*WalletTx
+	seen := make(map[string]bool)
+	include := func(tx *Tx, block *Block) {
+		if !seen[tx.ID] {
+			seen[tx.ID] = true
+			walletTxs = append(walletTxs, summarizeTx(tx, block, mine, b))
+		}
+	}
+	for address := range mine {
+		for _, txID := range txIDsByAddress(address) {
+			tx, block := locateTx(txID)
+			if tx == nil {
+				continue
+			}
+			include(tx, block)
+			for index, output := range tx.TxOuts {
+				if !mine[wallet.NormalizeAddress(output.Address)] {
+					continue
+				}
+				if spender, block := locateTx(spenderOf(tx.ID, index)); spender != nil {
+					include(spender, block)
+				}
+			}
+		}
+	}
+	for _, tx := range Mempool().Txs {
+		if touches(tx, mine) {
+			include(tx, nil)
+		}
+	}
+
+	sort.SliceStable(walletTxs, func(i, j int) bool {
+		if walletTxs[i].Confirmations != walletTxs[j].Confirmations {
+			return walletTxs[i].Confirmations < walletTxs[j].Confirmations
+		}
+		return walletTxs[i].Timestamp > walletTxs[j].Timestamp
+	})
+	return walletTxs
+}
+
+//touches return whether transaction pays to or spends from addresses of mine
+func touches(tx *Tx, mine map[string]bool) bool {
+	for _, output := range tx.TxOuts {
+		if mine[wallet.NormalizeAddress(output.Address)] {
+			return true
+		}
+	}
+	for _, input := range tx.TxIns {
+		if input.isCoinbase() {
+			continue
+		}
+		if prev := previousTxOut(input); prev != nil && mine[wallet.NormalizeAddress(prev.Address)] {
+			return true
+		}
+	}
+	return false
+}
+
+//summarizeTx make WalletTx of transaction seen from owner of addresses of mine
+//block is nil for transaction in mempool
+func summarizeTx(tx *Tx, block *Block, mine map[string]bool, b *blockChain) *WalletTx {
+	walletTx := &WalletTx{TxID: tx.ID, Timestamp: tx.Timestamp, Counterparties: []string{}}
+	if block != nil {
+		walletTx.BlockHash = block.Hash
+		walletTx.Confirmations = b.Height - block.Height + 1
+	}
+
+	spends, othersSpend := false, false
+	var senders, receivers []string
+	for _, input := range tx.TxIns {
+		if input.isCoinbase() {
+			walletTx.Coinbase = true
+			continue
+		}
+		prev := previousTxOut(input)
+		if prev == nil {
+			continue
+		}
+		owner := wallet.NormalizeAddress(prev.Address)
+		if mine[owner] {
+			spends = true
+			walletTx.add(prev.Token, -prev.Amount)
+			continue
+		}
+		othersSpend = true
+		if owner != "" {
+			senders = append(senders, owner)
+		}
+	}
+	for _, output := range tx.TxOuts {
+		owner := wallet.NormalizeAddress(output.Address)
+		if mine[owner] {
+			walletTx.add(output.Token, output.Amount)
+		} else if owner != "" {
+			receivers = append(receivers, owner)
+		}
+	}
+
+	switch {
+	case !spends:
+		walletTx.Direction = Incoming
+		walletTx.Counterparties = distinct(senders)
+	case !othersSpend && len(receivers) == 0:
+		walletTx.Direction = Self
+	default:
+		walletTx.Direction = Outgoing
+		walletTx.Counterparties = distinct(receivers)
+	}
+	return walletTx
+}
+
+// distinct return addresses without duplicates in order they first appear, never nil
+func distinct(addresses []string) []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, address := range addresses {
+		if !seen[address] {
+			seen[address] = true
+			result = append(result, address)
+		}
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+package blockchain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Gunyoung-Kim/blockchain/script"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+//ErrorNotHTLC is error returned when output is not hash time-locked contract
+var ErrorNotHTLC = errors.New("Output is not hash time-locked contract")
+
+//ErrorSpent is error returned when output is already spent
+var ErrorSpent = errors.New("Output is already spent")
+
+//ErrorInvalidHash is error returned when hash of contract is not hexa-decimal sha256 hash
+var ErrorInvalidHash = errors.New("Hash must be 64 lowercase hexa-decimal characters")
+
+//ErrorInvalidTimeout is error returned when timeout of contract is negative
+var ErrorInvalidTimeout = errors.New("Timeout must not be negative")
+
+//AddHTLC add transaction which locks amount from wallet of name from into hash time-locked contract to mempool
+//to can claim it with preimage of hash, address of wallet can refund it after timeout
+//timeout is block height if it is less than 500000000, otherwise unix timestamp, like OP_CHECKLOCKTIMEVERIFY
+func (m *mempool) AddHTLC(from, to string, amount int, hash string, timeout int) (*Tx, error) {
+	if !script.IsHash(hash) {
+		return nil, ErrorInvalidHash
+	}
+	if timeout < 0 {
+		return nil, ErrorInvalidTimeout
+	}
+	if err := wallet.ValidateAddress(to); err != nil {
+		return nil, err
+	}
+	w, err := wallet.Get(from)
+	if err != nil {
+		return nil, err
+	}
+	receiver, err := wallet.PublicKeyHashOf(to)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := wallet.PublicKeyHashOf(w.Address)
+	if err != nil {
+		return nil, err
+	}
+	output := &TxOut{"", amount, script.HashTimeLock(receiver, sender, hash, timeout), ""}
+	tx, err := makeTxWithOutput(w.Name, "", amount, output, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.add(tx)
+	return tx, nil
+}
+
+//ClaimHTLC add transaction which spends hash time-locked contract with preimage to mempool
+//it is signed by loaded wallet which has key of receiver of contract
+func (m *mempool) ClaimHTLC(txID string, index int, preimage string) (*Tx, error) {
+	return m.spendHTLC(txID, index, true, func(signature string) string {
+		return script.ClaimHashTimeLock(signature, preimage)
+	})
+}
+
+//RefundHTLC add transaction which spends hash time-locked contract after timeout to mempool
+//it is signed by loaded wallet which has key of sender of contract
+func (m *mempool) RefundHTLC(txID string, index int) (*Tx, error) {
+	return m.spendHTLC(txID, index, false, script.RefundHashTimeLock)
+}
+
+//spendHTLC make transaction which moves amount of hash time-locked contract to address of receiver or sender
+//address and wallet which signs are found from contract, unlock make unlocking script from credential of wallet
+func (m *mempool) spendHTLC(txID string, index int, receiver bool, unlock func(credential string) string) (*Tx, error) {
+	output := unspentTxOut(txID, index)
+	if output == nil {
+		if FindTxOut(BlockChain(), txID, index) != nil {
+			return nil, ErrorSpent
+		}
+		return nil, ErrNotFound
+	}
+	if !script.IsHashTimeLock(output.Script) {
+		return nil, ErrorNotHTLC
+	}
+	if isOnMempool(&UTxOut{TxID: txID, Index: index}) {
+		return nil, ErrorSpent
+	}
+
+	w, to, err := htlcParty(output.Script, receiver)
+	if err != nil {
+		return nil, err
+	}
+	tx := &Tx{
+		ID:        "",
+		Timestamp: int(time.Now().Unix()),
+		TxIns:     []*TxIn{{txID, index, ""}},
+		TxOuts:    []*TxOut{makeTxOut(to, output.Amount, "")},
+	}
+	tx.getID()
+	for _, txIn := range tx.TxIns {
+		credential, err := tx.credential(w, to, output.Script)
+		if err != nil {
+			return nil, err
+		}
+		txIn.Script = unlock(credential)
+	}
+	if !validate(tx) {
+		return nil, ErrorNotValid
+	}
+
+	m.add(tx)
+	return tx, nil
+}
+
+//htlcParty return loaded wallet and its address of receiver or sender of hash time-locked contract
+//contract made before hash of public key has public keys, which are legacy addresses
+func htlcParty(locking string, receiver bool) (Signer, string, error) {
+	receiverItem, senderItem, ok := script.HashTimeLockParties(locking)
+	if !ok {
+		return nil, "", ErrorNotHTLC
+	}
+	hash := senderItem
+	if receiver {
+		hash = receiverItem
+	}
+	if !script.RevealsPublicKey(locking) {
+		var err error
+		if hash, err = wallet.PublicKeyHashOf(hash); err != nil {
+			return nil, "", err
+		}
+	}
+	w, address, err := wallet.Owner(hash)
+	if err != nil {
+		return nil, "", err
+	}
+	return w, address, nil
+}
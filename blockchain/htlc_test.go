@@ -0,0 +1,78 @@
+package blockchain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Gunyoung-Kim/blockchain/script"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+func TestSpendHTLCWithKeyOfContract(t *testing.T) {
+	chain := BlockChain()
+	chain.AddBlock()
+
+	// receiver is second address of HD wallet, not its first one
+	receiver, err := wallet.Wallet().NewAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	preimage := wallet.NewPreimage()
+	locked, err := Mempool().AddHTLC(wallet.DefaultName, receiver, 10, script.Hash(preimage), chain.Height+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.AddBlock()
+	claim, err := Mempool().ClaimHTLC(locked.ID, len(locked.TxOuts)-1, preimage)
+	if err != nil {
+		t.Fatalf("ClaimHTLC: %v", err)
+	}
+	if claim.TxOuts[0].Address != wallet.NormalizeAddress(receiver) {
+		t.Errorf("claim pays to %s, want receiver %s", claim.TxOuts[0].Address, receiver)
+	}
+	chain.AddBlock()
+
+	// sender is named wallet, so refund is signed by it instead of default wallet
+	sender, err := wallet.Create("htlc-sender", "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Mempool().AddTx(wallet.DefaultName, sender.Address, 20, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	chain.AddBlock()
+	locked, err = Mempool().AddHTLC(sender.Name, receiver, 10, script.Hash(wallet.NewPreimage()), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.AddBlock()
+	refund, err := Mempool().RefundHTLC(locked.ID, len(locked.TxOuts)-1)
+	if err != nil {
+		t.Fatalf("RefundHTLC: %v", err)
+	}
+	if refund.TxOuts[0].Address != sender.Address {
+		t.Errorf("refund pays to %s, want sender %s", refund.TxOuts[0].Address, sender.Address)
+	}
+}
+
+func TestAddHTLCRejectsBadInput(t *testing.T) {
+	receiver := wallet.Wallet().Address
+	hash := script.Hash(wallet.NewPreimage())
+	tests := []struct {
+		name    string
+		hash    string
+		timeout int
+		want    error
+	}{
+		{"script in hash", hash[:62] + " OP_DROP 1 " + hash[:2], 10, ErrorInvalidHash},
+		{"uppercase hash", strings.ToUpper(hash), 10, ErrorInvalidHash},
+		{"short hash", hash[:62], 10, ErrorInvalidHash},
+		{"negative timeout", hash, -1, ErrorInvalidTimeout},
+	}
+	for _, test := range tests {
+		if _, err := Mempool().AddHTLC(wallet.DefaultName, receiver, 10, test.hash, test.timeout); !errors.Is(err, test.want) {
+			t.Errorf("%s: AddHTLC = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package blockchain
+
+import (
+	"sort"
+
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/script"
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+const (
+	utxoIndex    string = "utxo"    // index from outPoint to unspent output
+	txIndex      string = "tx"      // index from ID of transaction to hash of block which includes it
+	addressIndex string = "address" // index from address and ID of transaction which pays to address
+	spentIndex   string = "spent"   // index from outPoint to ID of transaction which spends it
+	tokenIndex   string = "token"   // index from ID of token to ID of transaction which issues it
+)
+
+//unspent is entry of utxoIndex
+type unspent struct {
+	TxID  string `json:"txID"`
+	Index int    `json:"index"`
+	TxOut *TxOut `json:"txOut"`
+}
+
+//indexBlock update all indexes by transactions in block
+//outputs of block are added to utxoIndex first, then outputs spent by block are removed
+//so output which is created and spent in same block doesn't remain
+func indexBlock(batch db.Batch, block *Block) {
+	for _, tx := range block.Transactions {
+		for index, output := range tx.TxOuts {
+			if script.IsData(output.Script) {
+				continue
+			}
+			entry := &unspent{tx.ID, index, output}
+			batch.SaveIndex(utxoIndex, outPoint(tx.ID, index), utils.ToBytes(entry))
+		}
+	}
+	for _, tx := range block.Transactions {
+		for _, input := range tx.TxIns {
+			if input.isCoinbase() {
+				continue
+			}
+			batch.DeleteIndex(utxoIndex, outPoint(input.TxID, input.Index))
+		}
+	}
+	indexHistory(batch, block)
+}
+
+//rebuildIndexes clear all indexes then index blocks from oldest to newest
+//blocks are ordered from newest to oldest like Blocks
+func rebuildIndexes(batch db.Batch, blocks []*Block) {
+	batch.ClearIndex(utxoIndex)
+	clearHistory(batch)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		indexBlock(batch, blocks[i])
+	}
+}
+
+//unspentTxOut return output of transaction at index if it is not spent in blockChain
+func unspentTxOut(txID string, index int) *TxOut {
+	data := store().Index(utxoIndex, outPoint(txID, index))
+	if data == nil {
+		return nil
+	}
+	entry := &unspent{}
+	utils.FromBytes(entry, data)
+	return entry.TxOut
+}
+
+//allUnspent return all entries of utxoIndex ordered by outPoint, they are read in one transaction
+func allUnspent() []*unspent {
+	return unspentIn(store())
+}
+
+//unspentIn return all entries of utxoIndex of s ordered by outPoint
+func unspentIn(s db.Store) []*unspent {
+	index := s.IndexPrefix(utxoIndex, "")
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries []*unspent
+	for _, key := range keys {
+		data := index[key]
+		entry := &unspent{}
+		utils.FromBytes(entry, data)
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,109 @@
+package blockchain
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+const (
+	maxBlockSize       int = 1 << 20   // maximum bytes of serialized block
+	maxTxSize          int = 100 << 10 // maximum bytes of serialized transaction
+	maxTxIns           int = 256
+	maxTxOuts          int = 256
+	maxFutureBlockTime int = 2 * 60 * 60 // seconds which block timestamp can be ahead of now
+	medianTimeSpan     int = 11          // number of previous blocks used for median time
+	blockSizeReserve   int = 4 << 10     // bytes of block reserved for header and coinbase
+)
+
+var (
+	//ErrBlockTooLarge is error returned when serialized block is bigger than maxBlockSize
+	ErrBlockTooLarge = errors.New("Block is too large")
+	//ErrTxTooLarge is error returned when serialized transaction is bigger than maxTxSize
+	ErrTxTooLarge = errors.New("Transaction is too large")
+	//ErrTooManyTxIns is error returned when transaction has more than maxTxIns inputs
+	ErrTooManyTxIns = errors.New("Transaction has too many inputs")
+	//ErrTooManyTxOuts is error returned when transaction has more than maxTxOuts outputs
+	ErrTooManyTxOuts = errors.New("Transaction has too many outputs")
+	//ErrTimestampTooNew is error returned when block timestamp is too far in the future
+	ErrTimestampTooNew = errors.New("Block timestamp is too far in the future")
+	//ErrTimestampTooOld is error returned when block timestamp is not greater than median of past blocks
+	ErrTimestampTooOld = errors.New("Block timestamp is not greater than median time of past blocks")
+)
+
+//checkTxLimits check serialized size and number of inputs and outputs of transaction
+func checkTxLimits(tx *Tx) error {
+	if len(tx.TxIns) > maxTxIns {
+		return ErrTooManyTxIns
+	}
+	if len(tx.TxOuts) > maxTxOuts {
+		return ErrTooManyTxOuts
+	}
+	if len(utils.ToBytes(tx)) > maxTxSize {
+		return ErrTxTooLarge
+	}
+	return nil
+}
+
+//checkBlockLimits check serialized size, transactions and timestamp of block
+//pastTimestamps is timestamps of blocks before block, newest first
+func checkBlockLimits(block *Block, pastTimestamps []int) error {
+	if len(utils.ToBytes(block)) > maxBlockSize {
+		return ErrBlockTooLarge
+	}
+	for _, tx := range block.Transactions {
+		if err := checkTxLimits(tx); err != nil {
+			return err
+		}
+	}
+	if block.Timestamp > int(time.Now().Unix())+maxFutureBlockTime {
+		return ErrTimestampTooNew
+	}
+	if len(pastTimestamps) > 0 && block.Timestamp <= medianTime(pastTimestamps) {
+		return ErrTimestampTooOld
+	}
+	return nil
+}
+
+//medianTime return median of timestamps of at most medianTimeSpan blocks
+func medianTime(timestamps []int) int {
+	if len(timestamps) > medianTimeSpan {
+		timestamps = timestamps[:medianTimeSpan]
+	}
+	sorted := append([]int{}, timestamps...)
+	sort.Ints(sorted)
+	return sorted[len(sorted)/2]
+}
+
+//pastTimestamps return timestamps of at most medianTimeSpan blocks from hash to its ancestors
+func pastTimestamps(hash string) []int {
+	var timestamps []int
+	for hash != "" && len(timestamps) < medianTimeSpan {
+		block, err := FindBlock(hash)
+		if err != nil {
+			break
+		}
+		timestamps = append(timestamps, block.Timestamp)
+		hash = block.PrevHash
+	}
+	return timestamps
+}
+
+//validateBlocks check limits of blocks which are ordered from newest to oldest like Blocks
+func validateBlocks(blocks []*Block) error {
+	for i, block := range blocks {
+		var timestamps []int
+		for _, past := range blocks[i+1:] {
+			if len(timestamps) == medianTimeSpan {
+				break
+			}
+			timestamps = append(timestamps, past.Timestamp)
+		}
+		if err := checkBlockLimits(block, timestamps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
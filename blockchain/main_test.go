@@ -0,0 +1,19 @@
+package blockchain
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Gunyoung-Kim/blockchain/config"
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/utils"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+// TestMain run tests against blockchain in memory mined by wallet in memory, so nothing is written on disk
+func TestMain(m *testing.M) {
+	config.UseMemory()
+	utils.HandleError(UseStore(db.NewMemoryStore()))
+	utils.HandleError(wallet.Open(""))
+	os.Exit(m.Run())
+}
@@ -0,0 +1,149 @@
+package blockchain
+
+import (
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+//migrations of schema of DB, version of each migration is its order
+func init() {
+	db.RegisterMigration(1, "index unspent outputs", indexUnspentOutputs)
+	db.RegisterMigration(2, "index transactions by address", indexTransactions)
+	db.RegisterMigration(3, "move input signatures into scripts", moveSignaturesToScripts)
+	db.RegisterMigration(4, "index issued tokens", indexTokens)
+}
+
+//indexUnspentOutputs build utxoIndex from blocks of DB made before utxoIndex is introduced
+func indexUnspentOutputs(s db.Store, batch db.Batch) error {
+	checkPoint := s.CheckPoint()
+	if checkPoint == nil {
+		return nil
+	}
+	chain := &blockChain{}
+	chain.restoreFromBytes(checkPoint)
+
+	var blocks []*Block
+	for hash := chain.NewestHash; hash != ""; {
+		block, err := findBlock(s, hash)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, block)
+		hash = block.PrevHash
+	}
+	rebuildIndexes(batch, blocks)
+	return nil
+}
+
+//indexTransactions build txIndex, addressIndex and spentIndex from blocks of DB made before they are introduced
+//utxoIndex is kept as it is, because bodies of pruned blocks can't rebuild it
+func indexTransactions(s db.Store, batch db.Batch) error {
+	checkPoint := s.CheckPoint()
+	if checkPoint == nil {
+		return nil
+	}
+	chain := &blockChain{}
+	chain.restoreFromBytes(checkPoint)
+
+	clearHistory(batch)
+	for hash := chain.NewestHash; hash != ""; {
+		block, err := findBlock(s, hash)
+		if err != nil {
+			return err
+		}
+		indexHistory(batch, block)
+		hash = block.PrevHash
+	}
+	return nil
+}
+
+//legacyBlock is block stored before unlocking script, whose inputs carry Signature instead of Script
+//gob matches fields by name, so decoding stored block into it reads only signatures of inputs
+type legacyBlock struct {
+	Transactions []*legacyTx
+}
+
+type legacyTx struct {
+	ID    string
+	TxIns []*legacyTxIn
+}
+
+type legacyTxIn struct {
+	TxID      string
+	Signature string
+}
+
+//moveSignaturesToScripts copy Signature of inputs stored before unlocking script into their Script
+//gob drops Signature when it decodes them into TxIn, so coinbase is not recognized and signatures are lost
+//signature alone is unlocking script of pay-to-address output, so it becomes Script as it is
+//utxoIndex is built from outputs and doesn't depend on them, but history indexes are built again from fixed blocks
+func moveSignaturesToScripts(s db.Store, batch db.Batch) error {
+	fixed := make(map[string]*Block)
+	for _, hash := range s.BlockHashes() {
+		data := s.Block(hash)
+		block := &Block{}
+		block.restoreFromBytes(data)
+		legacy := &legacyBlock{}
+		utils.FromBytes(legacy, data)
+
+		changed := false
+		for i, tx := range legacy.Transactions {
+			for j, txIn := range tx.TxIns {
+				target := block.Transactions[i].TxIns[j]
+				if txIn.Signature != "" && target.Script == "" {
+					target.Script = txIn.Signature
+					changed = true
+				}
+			}
+		}
+		if changed {
+			batch.SaveBlock(hash, utils.ToBytes(block))
+			fixed[hash] = block
+		}
+	}
+	if len(fixed) == 0 || s.CheckPoint() == nil {
+		return nil
+	}
+
+	chain := &blockChain{}
+	chain.restoreFromBytes(s.CheckPoint())
+	clearHistory(batch)
+	for hash := chain.NewestHash; hash != ""; {
+		block, ok := fixed[hash]
+		if !ok {
+			var err error
+			if block, err = findBlock(s, hash); err != nil {
+				return err
+			}
+		}
+		indexHistory(batch, block)
+		hash = block.PrevHash
+	}
+	return nil
+}
+
+//indexTokens build tokenIndex from blocks of DB made before it is introduced
+//blocks are walked from tip, so token issued more than once is indexed under its oldest issuance
+//issuances in pruned blocks can't be indexed because their bodies are deleted
+func indexTokens(s db.Store, batch db.Batch) error {
+	checkPoint := s.CheckPoint()
+	if checkPoint == nil {
+		return nil
+	}
+	chain := &blockChain{}
+	chain.restoreFromBytes(checkPoint)
+
+	for hash := chain.NewestHash; hash != ""; {
+		block, err := findBlock(s, hash)
+		if err != nil {
+			return err
+		}
+		for _, tx := range block.Transactions {
+			if tx.Issuance != nil {
+				batch.SaveIndex(tokenIndex, tx.Issuance.TokenID(), []byte(tx.ID))
+			}
+		}
+		hash = block.PrevHash
+	}
+	return nil
+}
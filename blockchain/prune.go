@@ -0,0 +1,76 @@
+package blockchain
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+const (
+	pruneIndex      string = "prune"        // index for pruning status
+	prunedHeightKey string = "prunedHeight" // key of pruneIndex, highest height whose body is pruned
+)
+
+//PruneDepth is number of newest blocks which keep their bodies
+//bodies of older blocks are deleted and only headers are kept, 0 means pruning is disabled
+var PruneDepth int
+
+//ErrPruned is error returned when operation needs bodies of blocks which are pruned
+var ErrPruned = errors.New("Blocks are pruned")
+
+//isPruned return whether body of block is deleted by pruning
+//every block which is not pruned has at least coinbase transaction
+func (b *Block) isPruned() bool {
+	return len(b.Transactions) == 0
+}
+
+//PrunedHeight return highest height whose block body is pruned, 0 if no block is pruned
+func PrunedHeight() int {
+	return prunedHeightIn(store())
+}
+
+//prunedHeightIn return height of newest pruned block in s, 0 if s is not pruned
+func prunedHeightIn(s db.Store) int {
+	height, err := strconv.Atoi(string(s.Index(pruneIndex, prunedHeightKey)))
+	if err != nil {
+		return 0
+	}
+	return height
+}
+
+//pruneBlocks delete bodies of blocks older than PruneDepth from tip of b
+//it is done after block is connected, so outputs of pruned blocks are already covered by utxoIndex
+func pruneBlocks(b *blockChain) {
+	if PruneDepth <= 0 || b.Height <= PruneDepth {
+		return
+	}
+	target := b.Height - PruneDepth
+	prunedHeight := PrunedHeight()
+	if target <= prunedHeight {
+		return
+	}
+
+	var headers []*Block
+	for hash := b.NewestHash; hash != ""; {
+		block, err := FindBlock(hash)
+		if err != nil || block.Height <= prunedHeight {
+			break
+		}
+		if block.Height <= target && !block.isPruned() {
+			header := *block
+			header.Transactions = nil
+			headers = append(headers, &header)
+		}
+		hash = block.PrevHash
+	}
+
+	utils.HandleError(store().Update(func(batch db.Batch) error {
+		for _, header := range headers {
+			batch.SaveBlock(header.Hash, utils.ToBytes(header))
+		}
+		batch.SaveIndex(pruneIndex, prunedHeightKey, []byte(strconv.Itoa(target)))
+		return nil
+	}))
+}
@@ -0,0 +1,92 @@
+package blockchain
+
+import (
+	"errors"
+
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+//ErrNoValidChain is error returned when no stored block makes valid chain to genesis
+var ErrNoValidChain = errors.New("There is no valid chain in DB")
+
+//Reindex rebuild derived state of DB from stored blocks without trusting checkpoint
+//it finds the highest block which makes valid chain to genesis, then rewrites checkpoint to it
+//and rebuilds all indexes from that chain in one batch
+//it must be called before BlockChain is used, and returns new tip
+//indexes can't be rebuilt if bodies of blocks on the chain are pruned
+func Reindex() (*Block, error) {
+	blocks := make(map[string]*Block)
+	for _, hash := range store().BlockHashes() {
+		if block, err := readBlock(store(), hash); err == nil && block.Hash == hash {
+			blocks[hash] = block
+		}
+	}
+
+	valid := make(map[string]bool)
+	var tip *Block
+	for _, block := range blocks {
+		if !validChain(block, blocks, valid) {
+			continue
+		}
+		if tip == nil || block.Height > tip.Height || (block.Height == tip.Height && block.Hash < tip.Hash) {
+			tip = block
+		}
+	}
+	if tip == nil {
+		return nil, ErrNoValidChain
+	}
+
+	var chain []*Block
+	for block := tip; block != nil; block = blocks[block.PrevHash] {
+		if block.isPruned() {
+			return nil, ErrPruned
+		}
+		chain = append(chain, block)
+	}
+	checkPoint := &blockChain{
+		NewestHash:        tip.Hash,
+		Height:            tip.Height,
+		CurrentDifficulty: tip.Difficulty,
+	}
+	err := store().Update(func(batch db.Batch) error {
+		batch.SaveCheckPoint(utils.ToBytes(checkPoint))
+		rebuildIndexes(batch, chain)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tip, nil
+}
+
+//validChain return whether block makes valid chain to genesis with blocks
+//each block on the way must have valid header and height one more than its previous block
+//result of each block is memoized in valid
+func validChain(block *Block, blocks map[string]*Block, valid map[string]bool) bool {
+	var path []*Block
+	result := false
+	for {
+		if known, ok := valid[block.Hash]; ok {
+			result = known
+			break
+		}
+		path = append(path, block)
+		if checkHeader(block) != nil {
+			break
+		}
+		if block.PrevHash == "" {
+			result = block.Height == 1
+			break
+		}
+		prev, ok := blocks[block.PrevHash]
+		if !ok || prev.Height != block.Height-1 {
+			break
+		}
+		block = prev
+	}
+	for _, visited := range path {
+		valid[visited.Hash] = result
+	}
+	return result
+}
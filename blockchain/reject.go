@@ -0,0 +1,95 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+// Codes of Rejection, they are stable so clients can handle them
+const (
+	RejectEncoding       string = "invalid-encoding"
+	RejectTxID           string = "bad-txid"
+	RejectLimits         string = "over-limits"
+	RejectKnown          string = "already-known"
+	RejectConflict       string = "mempool-conflict"
+	RejectMissingInput   string = "missing-input"
+	RejectDuplicateInput string = "duplicate-input"
+	RejectScript         string = "script-failed"
+	RejectIssuance       string = "bad-issuance"
+	RejectNegativeOutput string = "negative-output"
+	RejectAddress        string = "address-mismatch"
+	RejectDataSize       string = "data-too-large"
+	RejectToken          string = "token-not-conserved"
+	RejectInsufficient   string = "insufficient-funds"
+)
+
+var (
+	//ErrTxID is error returned when ID of transaction is not hash of its content
+	ErrTxID = errors.New("ID doesn't match transaction")
+	//ErrMissingInput is error returned when input spends output which doesn't exist or is already spent
+	ErrMissingInput = errors.New("Input spends unknown or spent output")
+	//ErrDuplicateInput is error returned when transaction spends same output twice
+	ErrDuplicateInput = errors.New("Transaction spends same output twice")
+	//ErrNegativeOutput is error returned when output has negative amount
+	ErrNegativeOutput = errors.New("Output amount is negative")
+	//ErrAddressMismatch is error returned when address of output is not one its locking script pays to
+	ErrAddressMismatch = errors.New("Output address doesn't match its locking script")
+	//ErrTokenNotConserved is error returned when amount of token in outputs differs from inputs
+	ErrTokenNotConserved = errors.New("Token amount in outputs doesn't match inputs")
+	//ErrInsufficientInputs is error returned when outputs spend more coin than inputs
+	ErrInsufficientInputs = errors.New("Outputs spend more than inputs")
+)
+
+//Rejection is structured reason why transaction is rejected
+//Input or Output is index of input or output which caused it, they are omitted when whole transaction is wrong
+type Rejection struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+	Input  *int   `json:"input,omitempty"`
+	Output *int   `json:"output,omitempty"`
+	err    error
+}
+
+func (r *Rejection) Error() string {
+	return r.Reason
+}
+
+func (r *Rejection) Unwrap() error {
+	return r.err
+}
+
+// reject make Rejection of code for whole transaction
+func reject(code string, err error) *Rejection {
+	return &Rejection{Code: code, Reason: err.Error(), err: err}
+}
+
+// rejectInput make Rejection of code for input at index
+func rejectInput(code string, index int, err error) *Rejection {
+	return &Rejection{Code: code, Reason: err.Error(), Input: &index, err: err}
+}
+
+// rejectOutput make Rejection of code for output at index
+func rejectOutput(code string, index int, err error) *Rejection {
+	return &Rejection{Code: code, Reason: err.Error(), Output: &index, err: err}
+}
+
+//EncodeTx return canonical encoding of transaction, hexa-decimal JSON of it
+func EncodeTx(tx *Tx) string {
+	return hex.EncodeToString(utils.ToJSON(tx))
+}
+
+//DecodeTx decode transaction from canonical encoding made by EncodeTx
+func DecodeTx(encoded string) (*Tx, error) {
+	data, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, reject(RejectEncoding, err)
+	}
+	tx := &Tx{}
+	if err := json.Unmarshal(data, tx); err != nil {
+		return nil, reject(RejectEncoding, err)
+	}
+	return tx, nil
+}
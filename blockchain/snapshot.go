@@ -0,0 +1,257 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/script"
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+const (
+	snapshotIndex string = "snapshot" // index for UTXO snapshot which blockChain is bootstrapped from
+	snapshotKey   string = "loaded"   // key of snapshotIndex, snapshotMeta until history is backfilled
+)
+
+var (
+	//ErrNotEmpty is error returned when UTXO snapshot is loaded into storage which already has blockchain
+	ErrNotEmpty = errors.New("DB already has blockchain")
+	//ErrBadCommitment is error returned when unspent outputs don't match commitment of UTXO snapshot
+	ErrBadCommitment = errors.New("Unspent outputs don't match commitment of snapshot")
+	//ErrUntrustedCommitment is error returned when commitment of UTXO snapshot is not one operator trusts
+	ErrUntrustedCommitment = errors.New("Commitment of snapshot doesn't match trusted commitment")
+	//ErrIncomplete is error returned when blocks for backfill don't cover chain of UTXO snapshot
+	ErrIncomplete = errors.New("Blocks don't cover chain of snapshot")
+)
+
+//UTXOSnapshot is set of unspent outputs and issued tokens at block Hash with headers of chain to genesis
+//Headers are ordered from newest to oldest like Blocks and have no transactions
+//Tokens map ID of each issued token to ID of transaction which issues it, like tokenIndex
+//Commitment is hash of Hash, Outputs ordered by outPoint and Tokens
+type UTXOSnapshot struct {
+	Hash       string            `json:"hash"`
+	Height     int               `json:"height"`
+	Difficulty int               `json:"difficulty"`
+	Headers    []*Block          `json:"headers"`
+	Outputs    []*unspent        `json:"outputs"`
+	Tokens     map[string]string `json:"tokens"`
+	Commitment string            `json:"commitment"`
+}
+
+//snapshotMeta is entry of snapshotIndex
+type snapshotMeta struct {
+	Hash       string
+	Height     int
+	Commitment string
+}
+
+//commitment return hash which commits unspent outputs and issued tokens at block of hash
+//tokens are committed too, otherwise node loading snapshot would accept issuance of token which already exists
+func commitment(hash string, outputs []*unspent, tokens map[string]string) string {
+	sort.Slice(outputs, func(i, j int) bool {
+		return outPoint(outputs[i].TxID, outputs[i].Index) < outPoint(outputs[j].TxID, outputs[j].Index)
+	})
+	return utils.Hash(hash + string(utils.ToJSON(outputs)) + string(utils.ToJSON(tokens)))
+}
+
+//issuedTokens return entries of tokenIndex
+func issuedTokens() map[string]string {
+	tokens := make(map[string]string)
+	for tokenID, txID := range store().IndexPrefix(tokenIndex, "") {
+		tokens[tokenID] = string(txID)
+	}
+	return tokens
+}
+
+//WriteUTXOSnapshot write unspent outputs at tip of b and headers of its chain to w as JSON
+//it returns written snapshot, whose Commitment is published for nodes which load it
+func WriteUTXOSnapshot(b *blockChain, w io.Writer) (*UTXOSnapshot, error) {
+	var headers []*Block
+	for _, block := range Blocks(b) {
+		header := *block
+		header.Transactions = nil
+		headers = append(headers, &header)
+	}
+	outputs := allUnspent()
+	tokens := issuedTokens()
+	snapshot := &UTXOSnapshot{
+		Hash:       b.NewestHash,
+		Height:     b.Height,
+		Difficulty: b.CurrentDifficulty,
+		Headers:    headers,
+		Outputs:    outputs,
+		Tokens:     tokens,
+		Commitment: commitment(b.NewestHash, outputs, tokens),
+	}
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+//LoadUTXOSnapshot read UTXO snapshot from r and bootstrap empty storage from it
+//snapshot checks its own commitment, so it is refused unless commitment is trusted one which operator got elsewhere
+//empty trusted skips that check and trusts whoever made snapshot file
+//headers are stored like pruned blocks, so blockChain validates new blocks from height of snapshot
+//while history before it is backfilled by Backfill
+//it must be called before BlockChain is used
+func LoadUTXOSnapshot(r io.Reader, trusted string) (*UTXOSnapshot, error) {
+	if store().CheckPoint() != nil {
+		return nil, ErrNotEmpty
+	}
+	snapshot := &UTXOSnapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return nil, err
+	}
+	if commitment(snapshot.Hash, snapshot.Outputs, snapshot.Tokens) != snapshot.Commitment {
+		return nil, ErrBadCommitment
+	}
+	if trusted != "" && snapshot.Commitment != trusted {
+		return nil, ErrUntrustedCommitment
+	}
+	headers := make(map[string]*Block)
+	for _, header := range snapshot.Headers {
+		headers[header.Hash] = header
+	}
+	tip, ok := headers[snapshot.Hash]
+	if !ok || tip.Height != snapshot.Height || len(headers) != snapshot.Height {
+		return nil, ErrBrokenChain
+	}
+	if !validChain(tip, headers, make(map[string]bool)) {
+		return nil, ErrBrokenChain
+	}
+
+	checkPoint := &blockChain{
+		NewestHash:        snapshot.Hash,
+		Height:            snapshot.Height,
+		CurrentDifficulty: snapshot.Difficulty,
+	}
+	meta := &snapshotMeta{snapshot.Hash, snapshot.Height, snapshot.Commitment}
+	err := store().Update(func(batch db.Batch) error {
+		for _, header := range snapshot.Headers {
+			header.Transactions = nil
+			batch.SaveBlock(header.Hash, utils.ToBytes(header))
+		}
+		batch.SaveCheckPoint(utils.ToBytes(checkPoint))
+		batch.ClearIndex(utxoIndex)
+		for _, entry := range snapshot.Outputs {
+			batch.SaveIndex(utxoIndex, outPoint(entry.TxID, entry.Index), utils.ToBytes(entry))
+		}
+		batch.ClearIndex(tokenIndex)
+		for tokenID, txID := range snapshot.Tokens {
+			batch.SaveIndex(tokenIndex, tokenID, []byte(txID))
+		}
+		batch.SaveIndex(pruneIndex, prunedHeightKey, []byte(strconv.Itoa(snapshot.Height)))
+		batch.SaveIndex(snapshotIndex, snapshotKey, utils.ToBytes(meta))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+//loadedSnapshot return snapshotMeta if history before UTXO snapshot is not backfilled yet
+func loadedSnapshot() *snapshotMeta {
+	data := store().Index(snapshotIndex, snapshotKey)
+	if data == nil {
+		return nil
+	}
+	meta := &snapshotMeta{}
+	utils.FromBytes(meta, data)
+	return meta
+}
+
+//Backfilling return whether blockChain is bootstrapped from UTXO snapshot and waits for its history
+//node in pruning mode doesn't backfill because history would be pruned again
+func Backfilling() bool {
+	return PruneDepth <= 0 && loadedSnapshot() != nil
+}
+
+//Backfill store bodies of blocks before UTXO snapshot
+//blocks must contain every block of chain from snapshot to genesis, others are ignored
+//transactions of them are replayed and resulting unspent outputs must match commitment of snapshot
+func Backfill(blocks []*Block) error {
+	meta := loadedSnapshot()
+	if meta == nil {
+		return nil
+	}
+	bodies := make(map[string]*Block)
+	for _, block := range blocks {
+		bodies[block.Hash] = block
+	}
+
+	var chain []*Block
+	for hash := meta.Hash; hash != ""; {
+		header, err := FindBlock(hash)
+		if err != nil {
+			return err
+		}
+		body, ok := bodies[hash]
+		if !ok || body.isPruned() || body.Height != header.Height || checkHeader(body) != nil {
+			return ErrIncomplete
+		}
+		chain = append(chain, body)
+		hash = header.PrevHash
+	}
+
+	outputs, tokens, err := replayUnspent(chain)
+	if err != nil {
+		return err
+	}
+	if commitment(meta.Hash, outputs, tokens) != meta.Commitment {
+		return ErrBadCommitment
+	}
+
+	return store().Update(func(batch db.Batch) error {
+		for _, block := range chain {
+			batch.SaveBlock(block.Hash, utils.ToBytes(block))
+			indexHistory(batch, block)
+		}
+		batch.DeleteIndex(pruneIndex, prunedHeightKey)
+		batch.DeleteIndex(snapshotIndex, snapshotKey)
+		return nil
+	})
+}
+
+//replayUnspent replay transactions of blocks from oldest to newest and return unspent outputs and issued tokens
+//blocks are ordered from newest to oldest like Blocks
+func replayUnspent(blocks []*Block) ([]*unspent, map[string]string, error) {
+	unspents := make(map[string]*unspent)
+	tokens := make(map[string]string)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		for _, tx := range block.Transactions {
+			ctx := &script.Context{Payload: tx.ID, Height: block.Height, Timestamp: block.Timestamp}
+			for _, txIn := range tx.TxIns {
+				if txIn.isCoinbase() {
+					continue
+				}
+				key := outPoint(txIn.TxID, txIn.Index)
+				prev, ok := unspents[key]
+				if !ok || script.Run(txIn.Script, prev.TxOut.lockingScript(), ctx) != nil {
+					return nil, nil, ErrInvalidTx
+				}
+				delete(unspents, key)
+			}
+			for index, output := range tx.TxOuts {
+				if !script.IsData(output.Script) {
+					unspents[outPoint(tx.ID, index)] = &unspent{tx.ID, index, output}
+				}
+			}
+			if tx.Issuance != nil {
+				tokens[tx.Issuance.TokenID()] = tx.ID
+			}
+		}
+	}
+
+	var outputs []*unspent
+	for _, entry := range unspents {
+		outputs = append(outputs, entry)
+	}
+	return outputs, tokens, nil
+}
@@ -0,0 +1,62 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+func TestLoadUTXOSnapshotWithTrustedCommitment(t *testing.T) {
+	chain := BlockChain()
+	if BalanceByAddress(wallet.Wallet().Address, chain) < issuanceFee {
+		chain.AddBlock()
+	}
+	issuance, err := Mempool().IssueToken("SNAPSHOT", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenID := issuance.Issuance.TokenID()
+	chain.AddBlock()
+	var file bytes.Buffer
+	written, err := WriteUTXOSnapshot(chain, &file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// snapshot is loaded into empty DB of other node
+	defer func(s db.Store) { storage = s }(storage)
+	load := func(trusted string) error {
+		if err := UseStore(db.NewMemoryStore()); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadUTXOSnapshot(bytes.NewReader(file.Bytes()), trusted)
+		return err
+	}
+
+	if err := load(written.Commitment[1:] + "0"); !errors.Is(err, ErrUntrustedCommitment) {
+		t.Errorf("loading with other trusted commitment = %v, want %v", err, ErrUntrustedCommitment)
+	}
+	if err := load(written.Commitment); err != nil {
+		t.Errorf("loading with trusted commitment = %v", err)
+	}
+	if !issued(tokenID) {
+		t.Error("token issued before snapshot is not issued after loading it")
+	}
+	if err := load(""); err != nil {
+		t.Errorf("loading without trusted commitment = %v", err)
+	}
+
+	// snapshot which hides issued token keeps commitment of original
+	delete(written.Tokens, tokenID)
+	file.Reset()
+	if err := json.NewEncoder(&file).Encode(written); err != nil {
+		t.Fatal(err)
+	}
+	if err := load(""); !errors.Is(err, ErrBadCommitment) {
+		t.Errorf("loading snapshot without its token = %v, want %v", err, ErrBadCommitment)
+	}
+}
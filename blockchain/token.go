@@ -0,0 +1,108 @@
+package blockchain
+
+import (
+	"errors"
+
+	"github.com/Gunyoung-Kim/blockchain/utils"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+const (
+	issuanceFee int = 1
+)
+
+//ErrorInvalidIssuance is error returned when ticker or supply of token is illegal
+var ErrorInvalidIssuance = errors.New("Ticker must not be empty and supply must be positive")
+
+//ErrorTokenExists is error returned when issuance makes token whose ID is already issued
+//ID of token is hash of its issuance, so same issuer can't issue same ticker and supply again
+var ErrorTokenExists = errors.New("Token is already issued")
+
+//TokenIssuance defines new token issued by transaction
+type TokenIssuance struct {
+	Ticker string `json:"ticker"`
+	Supply int    `json:"supply"`
+	Issuer string `json:"issuer"`
+}
+
+//Token is token issued in blockChain
+type Token struct {
+	ID string `json:"id"`
+	TokenIssuance
+}
+
+//TokenID return ID of token which is hash of issuance
+func (t *TokenIssuance) TokenID() string {
+	return utils.Hash(t)
+}
+
+//valid check ticker and supply of issuance
+//and issuer is owner of one of inputs which pays fee, so issuer signed it
+func (t *TokenIssuance) valid(owners []string) bool {
+	if t.Ticker == "" || t.Supply <= 0 {
+		return false
+	}
+	for _, owner := range owners {
+		if wallet.NormalizeAddress(owner) == wallet.NormalizeAddress(t.Issuer) {
+			return true
+		}
+	}
+	return false
+}
+
+//issued return whether token of ID is issued in blockChain
+//issuances in pruned blocks are known only if they were indexed before pruning
+func issued(tokenID string) bool {
+	return store().Index(tokenIndex, tokenID) != nil
+}
+
+//issuedOnMempool return whether transaction in mempool issues token of ID
+func issuedOnMempool(tokenID string) bool {
+	for _, pending := range Mempool().Txs {
+		if pending.Issuance != nil && pending.Issuance.TokenID() == tokenID {
+			return true
+		}
+	}
+	return false
+}
+
+//IssueToken add transaction which issues new token to mempool
+//whole supply of token goes to wallet and wallet pays issuanceFee
+func (m *mempool) IssueToken(ticker string, supply int) (*Tx, error) {
+	w, err := wallet.Get(wallet.DefaultName)
+	if err != nil {
+		return nil, err
+	}
+	from := w.Address
+	issuance := &TokenIssuance{ticker, supply, from}
+	if !issuance.valid([]string{from}) {
+		return nil, ErrorInvalidIssuance
+	}
+	if issued(issuance.TokenID()) || issuedOnMempool(issuance.TokenID()) {
+		return nil, ErrorTokenExists
+	}
+
+	tx, err := prepareTx([]string{from}, "", issuanceFee, makeTxOut(from, supply, issuance.TokenID()), nil)
+	if err != nil {
+		return nil, err
+	}
+	tx.Issuance = issuance
+	tx, err = finishTx(tx, wallet.DefaultName)
+	if err != nil {
+		return nil, err
+	}
+
+	m.add(tx)
+	return tx, nil
+}
+
+//Tokens return all tokens issued in blockChain
+func Tokens(b *blockChain) []*Token {
+	var tokens []*Token
+	for _, tx := range Transactions(b) {
+		if tx.Issuance != nil {
+			tokens = append(tokens, &Token{tx.Issuance.TokenID(), *tx.Issuance})
+		}
+	}
+	return tokens
+}
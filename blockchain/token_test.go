@@ -0,0 +1,66 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+func TestReissueToken(t *testing.T) {
+	chain := BlockChain()
+	chain.AddBlock()
+	address := wallet.Wallet().Address
+
+	tx, err := Mempool().IssueToken("REISSUE", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenID := tx.Issuance.TokenID()
+	if _, err := Mempool().IssueToken("REISSUE", 100); !errors.Is(err, ErrorTokenExists) {
+		t.Fatalf("issuing token in mempool again: got %v, want %v", err, ErrorTokenExists)
+	}
+	chain.AddBlock()
+	if got := TokenBalanceByAddress(address, tokenID, chain); got != 100 {
+		t.Fatalf("balance of token is %d, want 100", got)
+	}
+
+	if _, err := Mempool().IssueToken("REISSUE", 100); !errors.Is(err, ErrorTokenExists) {
+		t.Fatalf("issuing confirmed token again: got %v, want %v", err, ErrorTokenExists)
+	}
+
+	// issuance made without IssueToken, like one relayed by peer
+	reissue, err := prepareTx([]string{address}, "", issuanceFee, makeTxOut(address, 100, tokenID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reissue.Issuance = &TokenIssuance{"REISSUE", 100, address}
+	reissue.getID()
+	if err := reissue.sign(wallet.DefaultName); err != nil {
+		t.Fatal(err)
+	}
+	var rejection *Rejection
+	if err := verifyTx(reissue); !errors.As(err, &rejection) || rejection.Code != RejectIssuance {
+		t.Fatalf("verifyTx of reissue = %v, want rejection %s", err, RejectIssuance)
+	}
+	if err := Mempool().AddPeerTx(reissue); err == nil {
+		t.Fatal("AddPeerTx accepted reissue")
+	}
+
+	// block from peer carrying reissue, hash of block doesn't cover its transactions
+	block := createBlock(chain.NewestHash, chain.Height+1, getDifficulty(chain))
+	block.Transactions = append([]*Tx{reissue}, block.Transactions...)
+	if err := chain.AddPeerBlock(block); !errors.Is(err, ErrInvalidTx) {
+		t.Fatalf("AddPeerBlock of reissue = %v, want %v", err, ErrInvalidTx)
+	}
+
+	// reissue which gets into mempool is dropped when block is mined
+	Mempool().add(reissue)
+	chain.AddBlock()
+	if got := TokenBalanceByAddress(address, tokenID, chain); got != 100 {
+		t.Errorf("balance of token after mining reissue is %d, want 100", got)
+	}
+	if _, ok := Mempool().Txs[reissue.ID]; ok {
+		t.Error("reissue remains in mempool")
+	}
+}
@@ -2,15 +2,19 @@ package blockchain
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/Gunyoung-Kim/blockchain/script"
 	"github.com/Gunyoung-Kim/blockchain/utils"
 	"github.com/Gunyoung-Kim/blockchain/wallet"
 )
 
 const (
-	minerReward int = 50
+	minerReward    int    = 50
+	coinbaseScript string = "COINBASE"
 )
 
 type mempool struct {
@@ -31,58 +35,125 @@ func Mempool() *mempool {
 	return m
 }
 
-//AddTx add new transaction to mempool
-func (m *mempool) AddTx(to string, amount int) (*Tx, error) {
-	tx, err := makeTx(wallet.Wallet().Address, to, amount)
+//AddTx add new transaction which sends from wallet of name from to mempool
+//token is ID of token to send, empty token means coin, empty from means default wallet
+//outputs to spend are chosen by selector, nil selector means FirstFit
+func (m *mempool) AddTx(from, to string, amount int, token string, selector CoinSelector) (*Tx, error) {
+	tx, err := makeTx(from, to, token, amount, selector)
 
 	if err != nil {
 		return nil, err
 	}
 
-	m.Txs[tx.ID] = tx
+	m.add(tx)
 	return tx, nil
 }
 
-//txToConfirm confirm all transactions in mempool
-//get all transaction from mempool and add coinbaseTx then return transactions,
-//then initialize mempool
-func (m *mempool) txToConfirm() []*Tx {
-	coinbase := makeCoinbaseTx(wallet.Wallet().Address)
+//txToConfirm confirm transactions in mempool as many as block of height can hold
+//get transactions from mempool in order of timestamp until size of block reaches limit,
+//then add coinbaseTx which collects fees of them as well as minerReward and return transactions
+//transactions over limits of transaction, ones which are no longer valid, ones spending output which older one spends
+//and issuances of token which older one issues are dropped, others not confirmed remain in mempool
+func (m *mempool) txToConfirm(height int) []*Tx {
+	var pending []*Tx
+	for _, tx := range m.Txs {
+		pending = append(pending, tx)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Timestamp < pending[j].Timestamp
+	})
+
 	var txs []*Tx
-	for _, tx := range Mempool().Txs {
+	fees := 0
+	size := 0
+	tokens := make(map[string]bool)
+	spent := make(map[string]bool)
+	for _, tx := range pending {
+		if checkTxLimits(tx) != nil || verifyTx(tx) != nil || spendsAny(tx, spent) {
+			delete(m.Txs, tx.ID)
+			continue
+		}
+		if tx.Issuance != nil {
+			// only older one of issuances of same token is confirmed
+			if tokens[tx.Issuance.TokenID()] {
+				delete(m.Txs, tx.ID)
+				continue
+			}
+			tokens[tx.Issuance.TokenID()] = true
+		}
+		txSize := len(utils.ToBytes(tx))
+		if size+txSize > maxBlockSize-blockSizeReserve {
+			break
+		}
+		size += txSize
+		for _, txIn := range tx.TxIns {
+			spent[outPoint(txIn.TxID, txIn.Index)] = true
+		}
 		txs = append(txs, tx)
+		fees += tx.fee(indexView{})
+		delete(m.Txs, tx.ID)
 	}
+	// node opens default wallet before it mines, so miner is always found
+	miner, err := wallet.Get(wallet.DefaultName)
+	utils.HandleError(err)
+	coinbase := makeCoinbaseTx(miner.Address, fees, height)
 	txs = append(txs, coinbase)
-	m.Txs = make(map[string]*Tx)
 	return txs
 }
 
-func (m *mempool) AddPeerTx(tx *Tx) {
+//spendsAny return whether any input of tx spends output in spent
+func spendsAny(tx *Tx, spent map[string]bool) bool {
+	for _, txIn := range tx.TxIns {
+		if spent[outPoint(txIn.TxID, txIn.Index)] {
+			return true
+		}
+	}
+	return false
+}
+
+//AddPeerTx add transaction from peer to mempool if it doesn't go over limits of transaction and it is valid
+func (m *mempool) AddPeerTx(tx *Tx) error {
+	if err := checkTxLimits(tx); err != nil {
+		return err
+	}
+	if err := verifyTx(tx); err != nil {
+		return err
+	}
+
 	m.m.Lock()
 	defer m.m.Unlock()
 
-	m.Txs[tx.ID] = tx
+	m.add(tx)
+	return nil
 }
 
 //Tx is transaction
+//Issuance is only filled for transaction which issues new token
 type Tx struct {
-	ID        string   `json:"id"`
-	Timestamp int      `json:"timestamp"`
-	TxIns     []*TxIn  `json:"txIns"`
-	TxOuts    []*TxOut `json:"txOuts"`
+	ID        string         `json:"id"`
+	Timestamp int            `json:"timestamp"`
+	TxIns     []*TxIn        `json:"txIns"`
+	TxOuts    []*TxOut       `json:"txOuts"`
+	Issuance  *TokenIssuance `json:"issuance,omitempty"`
 }
 
 //TxIn represents input for transaction
+//Script is unlocking script which satisfies locking script of output it spends
+//for output paid to hash of public key, it carries public key after signature
 type TxIn struct {
-	TxID      string `json:"txID"`
-	Index     int    `json:"index"`
-	Signature string `json:"signature"`
+	TxID   string `json:"txID"`
+	Index  int    `json:"index"`
+	Script string `json:"script"`
 }
 
 //TxOut represents output for transaction
+//Script is locking script, Address is owner of output for standard script template
+//Token is ID of token which output carries, empty Token means coin
 type TxOut struct {
 	Address string `json:"address"`
 	Amount  int    `json:"amount"`
+	Script  string `json:"script"`
+	Token   string `json:"token,omitempty"`
 }
 
 //UTxOut represents TxOut which is not used for input of transaction
@@ -90,40 +161,213 @@ type UTxOut struct {
 	TxID   string `json:"txID"`
 	Index  int    `json:"index"`
 	Amount int    `json:"amount"`
+	Token  string `json:"token,omitempty"`
+}
+
+//makeTxOut make TxOut of token with standard script template for address
+//output is paid to hash of public key, address must be validated before
+func makeTxOut(address string, amount int, token string) *TxOut {
+	address = wallet.NormalizeAddress(address)
+	hash, err := wallet.PublicKeyHashOf(address)
+	utils.HandleError(err)
+	return &TxOut{address, amount, script.PayToPubKeyHash(hash), token}
+}
+
+//fee return difference between total amount of coin in inputs and outputs of Tx
+//it is collected by miner who confirms Tx
+//it is never negative, so Tx whose inputs are spent can't take reward of miner away
+func (t *Tx) fee(view chainView) int {
+	fee := 0
+	for _, txIn := range t.TxIns {
+		if prevTxOut := view.unspent(txIn.TxID, txIn.Index); prevTxOut != nil && prevTxOut.Token == "" {
+			fee += prevTxOut.Amount
+		}
+	}
+	for _, txOut := range t.TxOuts {
+		if txOut.Token == "" {
+			fee -= txOut.Amount
+		}
+	}
+	if fee < 0 {
+		return 0
+	}
+	return fee
 }
 
 //getID create ID for Tx by hashing another field of Tx
+//ID of transaction which spends outputs is unsignedID, so anyone can check what its signatures sign
 func (t *Tx) getID() {
-	t.ID = utils.Hash(t)
+	t.ID = t.unsignedID()
+}
+
+//coinbaseID return ID of coinbase transaction of block at height
+//coinbase transaction spends nothing, so height of its block makes it unique
+func coinbaseID(t *Tx, height int) string {
+	return utils.Hash(fmt.Sprintf("%s:%d", t.unsignedID(), height))
+}
+
+//unsignedID return hash of JSON of Tx without ID and unlocking scripts
+//it is unique because each output is spent only once
+func (t *Tx) unsignedID() string {
+	unsigned := *t
+	unsigned.ID = ""
+	unsigned.TxIns = nil
+	for _, txIn := range t.TxIns {
+		unsigned.TxIns = append(unsigned.TxIns, &TxIn{txIn.TxID, txIn.Index, ""})
+	}
+	return utils.Hash(string(utils.ToJSON(&unsigned)))
+}
+
+//isCoinbase return whether txIn is input of coinbase transaction
+func (t *TxIn) isCoinbase() bool {
+	return t.Script == coinbaseScript
+}
+
+//lockingScript return locking script of txOut
+//outputs made before script was introduced are treated as pay-to-address
+func (t *TxOut) lockingScript() string {
+	if t.Script == "" {
+		return script.PayToAddress(t.Address)
+	}
+	return t.Script
 }
 
-//sign inject signature into transaction made by transaction id, private key in wallet
-func (t *Tx) sign() {
+//matchesScript return whether locking script of txOut pays to its address
+//balance and history of address count outputs by address, so they must be spendable by owner of address
+//output without address is locked by its script alone
+func (t *TxOut) matchesScript() bool {
+	if t.Address == "" || t.Script == "" || t.Script == script.PayToAddress(t.Address) {
+		return true
+	}
+	hash, err := wallet.PublicKeyHashOf(t.Address)
+	return err == nil && t.Script == script.PayToPubKeyHash(hash)
+}
+
+//sign inject unlocking script into transaction made by signature of transaction id with private key in wallet of name
+//each input is signed with key of address which owns output it spends
+//it returns error if wallet can't sign
+func (t *Tx) sign(name string) error {
+	w, err := wallet.Get(name)
+	if err != nil {
+		return err
+	}
 	for _, txIn := range t.TxIns {
-		txIn.Signature = wallet.Sign(t.ID, wallet.Wallet())
+		prevTxOut := unspentTxOut(txIn.TxID, txIn.Index)
+		if prevTxOut == nil {
+			return ErrorNotValid
+		}
+		credential, err := t.credential(w, prevTxOut.Address, prevTxOut.lockingScript())
+		if err != nil {
+			return err
+		}
+		txIn.Script = credential
 	}
+	return nil
+}
+
+//Signer is owner of keys which signs transaction, wallets implement it
+type Signer interface {
+	SignAs(payload, address string) (string, error)
+	PublicKeyAs(address string) (string, error)
+}
+
+//credential return signature of transaction id with key of address in signer
+//public key of address follows it if locking script checks hash of public key
+func (t *Tx) credential(s Signer, address, locking string) (string, error) {
+	signature, err := s.SignAs(t.ID, address)
+	if err != nil {
+		return "", err
+	}
+	if !script.RevealsPublicKey(locking) {
+		return signature, nil
+	}
+	publicKey, err := s.PublicKeyAs(address)
+	if err != nil {
+		return "", err
+	}
+	return script.Unlock(signature, publicKey), nil
 }
 
 //validate check input transaction is legal.
-//First check txIn in Transaction is in previous transaction
-//Second check txOut's address in that transaction
+//it is same as verifyTx without reason
 func validate(t *Tx) bool {
-	valid := true
+	return verifyTx(t) == nil
+}
 
-	for _, txIn := range t.TxIns {
-		prevTx := FindTransaction(BlockChain(), txIn.TxID)
-		if prevTx == nil {
-			valid = false
-			break
+//verifyTx check input transaction is legal and return Rejection with reason if it is not
+//it is verified against blockChain in DB as if it were in next block, and its data must fit MaxDataSize
+func verifyTx(t *Tx) error {
+	if err := checkDataSize(t); err != nil {
+		return err
+	}
+	return verifyTxAt(t, indexView{}, BlockChain().Height+1, int(time.Now().Unix()))
+}
+
+//verifyTxAt check transaction is legal in block of height and timestamp on top of view
+//First check ID of transaction is hash of its content, because signatures sign only ID
+//Second check txIn in Transaction spends output which is not spent yet, only once in transaction
+//Third run unlocking script of txIn against locking script of txOut in that transaction
+//Then check address of each txOut matches its locking script
+//Last check amount of every token is conserved and amount of coin in outputs doesn't exceed inputs
+func verifyTxAt(t *Tx, view chainView, height, timestamp int) error {
+	if t.ID != t.unsignedID() {
+		return reject(RejectTxID, ErrTxID)
+	}
+	ctx := &script.Context{
+		Payload:   t.ID,
+		Height:    height,
+		Timestamp: timestamp,
+	}
+
+	totals := make(map[string]int)
+	var owners []string
+	spent := make(map[string]bool)
+	for index, txIn := range t.TxIns {
+		key := outPoint(txIn.TxID, txIn.Index)
+		if spent[key] {
+			return rejectInput(RejectDuplicateInput, index, ErrDuplicateInput)
 		}
-		address := prevTx.TxOuts[txIn.Index].Address
-		valid = wallet.Verify(txIn.Signature, t.ID, address)
-		if !valid {
-			break
+		spent[key] = true
+		prevTxOut := view.unspent(txIn.TxID, txIn.Index)
+		if prevTxOut == nil {
+			return rejectInput(RejectMissingInput, index, ErrMissingInput)
+		}
+		if err := script.Run(txIn.Script, prevTxOut.lockingScript(), ctx); err != nil {
+			return rejectInput(RejectScript, index, err)
+		}
+		totals[prevTxOut.Token] += prevTxOut.Amount
+		owners = append(owners, prevTxOut.Address)
+	}
+
+	if t.Issuance != nil {
+		if !t.Issuance.valid(owners) {
+			return reject(RejectIssuance, ErrorInvalidIssuance)
+		}
+		if view.issued(t.Issuance.TokenID()) {
+			return reject(RejectIssuance, ErrorTokenExists)
 		}
+		totals[t.Issuance.TokenID()] += t.Issuance.Supply
 	}
 
-	return valid
+	for index, txOut := range t.TxOuts {
+		if txOut.Amount < 0 {
+			return rejectOutput(RejectNegativeOutput, index, ErrNegativeOutput)
+		}
+		if !txOut.matchesScript() {
+			return rejectOutput(RejectAddress, index, ErrAddressMismatch)
+		}
+		totals[txOut.Token] -= txOut.Amount
+	}
+
+	for token, total := range totals {
+		if token != "" && total != 0 {
+			return reject(RejectToken, ErrTokenNotConserved)
+		}
+		if total < 0 {
+			return reject(RejectInsufficient, ErrInsufficientInputs)
+		}
+	}
+	return nil
 }
 
 //isOnMempool check UTxOut is in TxIns in Tx in mempool before add to result of unusedTxOut
@@ -139,14 +383,15 @@ func isOnMempool(uTxOut *UTxOut) bool {
 	return false
 }
 
-//makeCoinbaseTx make Tx from coinbase for miner
-func makeCoinbaseTx(address string) *Tx {
+//makeCoinbaseTx make Tx from coinbase for miner of block at height
+//miner gets fees of confirmed transactions with minerReward
+func makeCoinbaseTx(address string, fees, height int) *Tx {
 	txIns := []*TxIn{
-		{"", -1, "COINBASE"},
+		{"", -1, coinbaseScript},
 	}
 
 	txOuts := []*TxOut{
-		{address, minerReward},
+		makeTxOut(address, minerReward+fees, ""),
 	}
 
 	tx := Tx{
@@ -156,7 +401,7 @@ func makeCoinbaseTx(address string) *Tx {
 		TxOuts:    txOuts,
 	}
 
-	tx.getID()
+	tx.ID = coinbaseID(&tx, height)
 	return &tx
 }
 
@@ -166,43 +411,75 @@ var ErrorNoMoney = errors.New("Not enough balance")
 //ErrorNotValid is error returned when transaction don't pass valid check
 var ErrorNotValid = errors.New("Transaction is non-valid")
 
-//makeTx make transction for input amount
-//first check from has enough balance by blockchain
-//then get all unusedTxOuts and add one to one, make txIn until total is bigger than or equal to amount
-//if total is bigger than amount then append changeTxOut to txOuts of new Tx
-func makeTx(from, to string, amount int) (*Tx, error) {
-	if BalanceByAddress(from, BlockChain()) < amount {
-		return nil, ErrorNoMoney
+//makeTx make transction for input amount of token from wallet of name from
+//it makes pay-to-address output for to and delegates to makeTxWithOutput
+func makeTx(from, to, token string, amount int, selector CoinSelector) (*Tx, error) {
+	if err := wallet.ValidateAddress(to); err != nil {
+		return nil, err
+	}
+	return makeTxWithOutput(from, token, amount, makeTxOut(to, amount, token), selector)
+}
+
+//makeTxWithOutput make transction which pays amount of token from wallet of name from to output
+//then sign and validate it
+func makeTxWithOutput(from, token string, amount int, output *TxOut, selector CoinSelector) (*Tx, error) {
+	w, err := wallet.Get(from)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := prepareTx(w.Addresses(), token, amount, output, selector)
+	if err != nil {
+		return nil, err
+	}
+	return finishTx(tx, from)
+}
+
+//prepareTx make unsigned transction which pays amount of token from addresses of from to output
+//unspent outputs of from are chosen by selector, nil selector means FirstFit
+//if total is bigger than amount then append changeTxOut for first address of from to txOuts of new Tx
+func prepareTx(from []string, token string, amount int, output *TxOut, selector CoinSelector) (*Tx, error) {
+	var candidates []*UTxOut
+	for _, address := range from {
+		candidates = append(candidates, TokenUTxOutsByAddress(address, token, BlockChain())...)
+	}
+	if selector == nil {
+		selector = firstFit{}
+	}
+	selected, err := selector.Select(candidates, amount)
+	if err != nil {
+		return nil, err
 	}
 
 	var txOuts []*TxOut
 	var txIns []*TxIn
 	total := 0
-	uTxOuts := UTxOutsByAddress(from, BlockChain())
-	for _, uTxOut := range uTxOuts {
-		if total >= amount {
-			break
-		}
-		txIn := &TxIn{uTxOut.TxID, uTxOut.Index, from}
+	for _, uTxOut := range selected {
+		txIn := &TxIn{uTxOut.TxID, uTxOut.Index, ""}
 		txIns = append(txIns, txIn)
 		total += uTxOut.Amount
 	}
 
 	if change := total - amount; change != 0 {
-		changeTxOut := &TxOut{from, change}
+		changeTxOut := makeTxOut(from[0], change, token)
 		txOuts = append(txOuts, changeTxOut)
 	}
 
-	txOut := &TxOut{to, amount}
-	txOuts = append(txOuts, txOut)
+	txOuts = append(txOuts, output)
 	tx := &Tx{
 		ID:        "",
 		Timestamp: int(time.Now().Unix()),
 		TxIns:     txIns,
 		TxOuts:    txOuts,
 	}
+	return tx, nil
+}
+
+//finishTx give ID to transaction, sign with wallet of name and validate it
+func finishTx(tx *Tx, name string) (*Tx, error) {
 	tx.getID()
-	tx.sign()
+	if err := tx.sign(name); err != nil {
+		return nil, err
+	}
 	valid := validate(tx)
 	if !valid {
 		return nil, ErrorNotValid
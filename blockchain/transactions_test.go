@@ -0,0 +1,164 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+func TestMineTxSpendingSpentOutputs(t *testing.T) {
+	chain := BlockChain()
+	chain.AddBlock()
+	address := wallet.Wallet().Address
+
+	tx, err := Mempool().AddTx(wallet.DefaultName, address, 10, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.AddBlock()
+
+	// transaction whose inputs are spent by block comes back, like one relayed late by peer
+	Mempool().add(tx)
+	block := chain.AddBlock()
+	if len(block.Transactions) != 1 {
+		t.Fatalf("block has %d transactions, want only coinbase", len(block.Transactions))
+	}
+	reward := 0
+	for _, txOut := range block.Transactions[0].TxOuts {
+		reward += txOut.Amount
+	}
+	if reward != minerReward {
+		t.Errorf("coinbase pays %d, want %d", reward, minerReward)
+	}
+	if _, ok := Mempool().Txs[tx.ID]; ok {
+		t.Error("transaction spending spent outputs remains in mempool")
+	}
+}
+
+func TestOutputAddressMustMatchScript(t *testing.T) {
+	chain := BlockChain()
+	chain.AddBlock()
+	address := wallet.Wallet().Address
+	other, err := wallet.Wallet().NewAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// output which claims to pay other but is locked to address
+	tx, err := prepareTx([]string{address}, "", 10, makeTxOut(address, 10, ""), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.TxOuts[0].Address = other
+	tx.getID()
+	if err := tx.sign(wallet.DefaultName); err != nil {
+		t.Fatal(err)
+	}
+	var rejection *Rejection
+	if err := verifyTx(tx); !errors.As(err, &rejection) || rejection.Code != RejectAddress {
+		t.Fatalf("verifyTx = %v, want rejection %s", err, RejectAddress)
+	}
+	if rejection.Output == nil || *rejection.Output != 0 || rejection.Input != nil {
+		t.Errorf("rejection points at input %v, output %v, want output 0", rejection.Input, rejection.Output)
+	}
+
+	block := createBlock(chain.NewestHash, chain.Height+1, getDifficulty(chain))
+	coinbase := block.Transactions[len(block.Transactions)-1]
+	coinbase.TxOuts[0].Address = other
+	coinbase.ID = coinbaseID(coinbase, block.Height)
+	if err := chain.AddPeerBlock(block); !errors.Is(err, ErrInvalidCoinbase) {
+		t.Fatalf("AddPeerBlock with mismatched coinbase = %v, want %v", err, ErrInvalidCoinbase)
+	}
+}
+
+func TestTxIDMustMatchContent(t *testing.T) {
+	chain := BlockChain()
+	address := wallet.Wallet().Address
+	if BalanceByAddress(address, chain) < 10 {
+		chain.AddBlock()
+	}
+	other, err := wallet.Wallet().NewAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := Mempool().AddTx(wallet.DefaultName, address, 10, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// signed transaction whose output is redirected in transit keeps its ID and signatures
+	tampered := *tx
+	tampered.TxOuts = append([]*TxOut{}, tx.TxOuts...)
+	tampered.TxOuts[len(tampered.TxOuts)-1] = makeTxOut(other, 10, "")
+	var rejection *Rejection
+	if err := verifyTx(&tampered); !errors.As(err, &rejection) || rejection.Code != RejectTxID {
+		t.Fatalf("verifyTx of tampered transaction = %v, want rejection %s", err, RejectTxID)
+	}
+	height := chain.Height + 1
+	block := &Block{Height: height, Transactions: []*Tx{&tampered, makeCoinbaseTx(address, tx.fee(indexView{}), height)}}
+	if err := checkTransactions(block, indexView{}); !errors.Is(err, ErrInvalidTx) {
+		t.Fatalf("checkTransactions with tampered transaction = %v, want %v", err, ErrInvalidTx)
+	}
+}
+
+func TestTxIDMustBeUnique(t *testing.T) {
+	chain := BlockChain()
+	mined, err := FindBlock(chain.NewestHash)
+	if err != nil {
+		mined = chain.AddBlock()
+	}
+	address := wallet.Wallet().Address
+
+	coinbase := makeCoinbaseTx(address, 0, chain.Height+1)
+	if coinbase.ID != coinbaseID(coinbase, chain.Height+1) || coinbase.ID == makeCoinbaseTx(address, 0, chain.Height+2).ID {
+		t.Fatal("coinbase ID isn't derived from its content and height")
+	}
+	tests := []struct {
+		name  string
+		block *Block
+		want  error
+	}{
+		{"same ID twice in block", &Block{Height: chain.Height + 1, Transactions: []*Tx{coinbase, coinbase}}, ErrInvalidTx},
+		{"ID in chain", &Block{Height: mined.Height, Transactions: mined.Transactions}, ErrInvalidTx},
+		{"coinbase ID of other height", &Block{Height: chain.Height + 2, Transactions: []*Tx{coinbase}}, ErrInvalidCoinbase},
+		{"unique", &Block{Height: chain.Height + 1, Transactions: []*Tx{coinbase}}, nil},
+	}
+	for _, test := range tests {
+		if err := checkTransactions(test.block, indexView{}); !errors.Is(err, test.want) {
+			t.Errorf("%s: checkTransactions = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestPeerTxWithRewrittenOutputs(t *testing.T) {
+	chain := BlockChain()
+	address := wallet.Wallet().Address
+	if BalanceByAddress(address, chain) < 10 {
+		chain.AddBlock()
+	}
+	other, err := wallet.Wallet().NewAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := prepareTx([]string{address}, "", 10, makeTxOut(address, 10, ""), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.getID()
+	if err := tx.sign(wallet.DefaultName); err != nil {
+		t.Fatal(err)
+	}
+
+	// peer relays signed transaction with its payment redirected, keeping ID and unlocking scripts
+	tx.TxOuts[len(tx.TxOuts)-1] = makeTxOut(other, 10, "")
+	for name, add := range map[string]func(*Tx) error{"AddPeerTx": Mempool().AddPeerTx, "AddRawTx": Mempool().AddRawTx} {
+		var rejection *Rejection
+		if err := add(tx); !errors.As(err, &rejection) || rejection.Code != RejectTxID {
+			t.Errorf("%s = %v, want rejection %s", name, err, RejectTxID)
+		}
+	}
+	if _, ok := Mempool().Txs[tx.ID]; ok {
+		t.Error("transaction with rewritten outputs is in mempool")
+	}
+}
@@ -0,0 +1,95 @@
+package blockchain
+
+import (
+	"errors"
+
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+var (
+	//ErrInputsMismatch is error returned when previous outputs of unsigned transaction don't match its inputs
+	ErrInputsMismatch = errors.New("Previous outputs don't match inputs")
+	//ErrAlreadyKnown is error returned when transaction is already in mempool
+	ErrAlreadyKnown = errors.New("Transaction is already in mempool")
+)
+
+//UnsignedTx is transaction whose inputs are not signed yet
+//Inputs are previous outputs which TxIns spend in same order, so it can be signed without blockchain
+type UnsignedTx struct {
+	Tx     *Tx      `json:"tx"`
+	Inputs []*TxOut `json:"inputs"`
+}
+
+//BuildTx make unsigned transaction which pays amount of token from address from to address to
+//change goes back to from, it is signed by SignTx out of node and submitted by AddRawTx
+//outputs to spend are chosen by selector, nil selector means FirstFit
+func BuildTx(from, to, token string, amount int, selector CoinSelector) (*UnsignedTx, error) {
+	if err := wallet.ValidateAddress(from); err != nil {
+		return nil, err
+	}
+	if err := wallet.ValidateAddress(to); err != nil {
+		return nil, err
+	}
+	tx, err := prepareTx([]string{from}, token, amount, makeTxOut(to, amount, token), selector)
+	if err != nil {
+		return nil, err
+	}
+	tx.getID()
+
+	unsigned := &UnsignedTx{Tx: tx}
+	for _, txIn := range tx.TxIns {
+		prevTxOut := unspentTxOut(txIn.TxID, txIn.Index)
+		if prevTxOut == nil {
+			return nil, ErrorNotValid
+		}
+		unsigned.Inputs = append(unsigned.Inputs, prevTxOut)
+	}
+	return unsigned, nil
+}
+
+//SignTx sign every input of unsigned transaction with key of address which owns its previous output
+//it checks ID of transaction first, so signer never signs other transaction than it reads
+func SignTx(unsigned *UnsignedTx, s Signer) (*Tx, error) {
+	tx := unsigned.Tx
+	if tx == nil || len(unsigned.Inputs) != len(tx.TxIns) {
+		return nil, ErrInputsMismatch
+	}
+	if tx.ID != tx.unsignedID() {
+		return nil, ErrTxID
+	}
+	for i, txIn := range tx.TxIns {
+		input := unsigned.Inputs[i]
+		credential, err := tx.credential(s, input.Address, input.lockingScript())
+		if err != nil {
+			return nil, err
+		}
+		txIn.Script = credential
+	}
+	return tx, nil
+}
+
+//AddRawTx add transaction signed out of node to mempool after validating it
+//it must not spend output which transaction in mempool spends
+//error returned is Rejection, which tells why transaction is rejected
+func (m *mempool) AddRawTx(tx *Tx) error {
+	if err := checkTxLimits(tx); err != nil {
+		return reject(RejectLimits, err)
+	}
+	if err := verifyTx(tx); err != nil {
+		return err
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if _, ok := m.Txs[tx.ID]; ok {
+		return reject(RejectKnown, ErrAlreadyKnown)
+	}
+	for index, txIn := range tx.TxIns {
+		if isOnMempool(&UTxOut{TxID: txIn.TxID, Index: txIn.Index}) {
+			return rejectInput(RejectConflict, index, ErrorSpent)
+		}
+	}
+	m.add(tx)
+	return nil
+}
@@ -0,0 +1,181 @@
+package blockchain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/script"
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+//Finding is problem found while verifying blockChain
+type Finding struct {
+	Height  int    `json:"height,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+	Problem string `json:"problem"`
+}
+
+//VerifyReport is result of verifying blockChain
+type VerifyReport struct {
+	NewestHash    string     `json:"newestHash"`
+	Height        int        `json:"height"`
+	PrunedHeight  int        `json:"prunedHeight,omitempty"`
+	CheckedBlocks int        `json:"checkedBlocks"`
+	CheckedTxs    int        `json:"checkedTxs"`
+	Orphans       []string   `json:"orphans"`
+	Findings      []*Finding `json:"findings"`
+	OK            bool       `json:"ok"`
+}
+
+func (r *VerifyReport) add(block *Block, format string, a ...interface{}) {
+	finding := &Finding{Problem: fmt.Sprintf(format, a...)}
+	if block != nil {
+		finding.Height = block.Height
+		finding.Hash = block.Hash
+	}
+	r.Findings = append(r.Findings, finding)
+}
+
+//checkHeader check hash of block matches its content and proof of work
+func checkHeader(block *Block) error {
+	if block.Hash != block.calculateHash() || !strings.HasPrefix(block.Hash, strings.Repeat("0", block.Difficulty)) {
+		return ErrInvalidHash
+	}
+	return nil
+}
+
+//readBlock find block from s, block which can't be decoded is reported as error
+func readBlock(s db.Store, hash string) (block *Block, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			block, err = nil, fmt.Errorf("block can't be decoded: %v", r)
+		}
+	}()
+	return findBlock(s, hash)
+}
+
+//Verify audit DB of blockChain
+//it walks from tip to genesis checking hash, proof of work, PrevHash link and height of each block,
+//then replays transactions from genesis checking scripts of inputs against outputs they spend
+//and compares replayed unspent outputs, issued tokens and blocks of transactions with utxoIndex, tokenIndex and txIndex
+//blocks in DB which are not reachable from NewestHash are reported as orphans
+//transactions are not replayed if bodies of blocks are pruned
+func Verify(b *blockChain) *VerifyReport {
+	return verifyStore(store(), b)
+}
+
+//verifyStore audit s whose checkpoint is b like Verify
+func verifyStore(s db.Store, b *blockChain) *VerifyReport {
+	report := &VerifyReport{NewestHash: b.NewestHash, Height: b.Height, Orphans: []string{}, Findings: []*Finding{}}
+	report.PrunedHeight = prunedHeightIn(s)
+
+	var blocks []*Block
+	reachable := make(map[string]bool)
+	height := b.Height
+	for hash := b.NewestHash; hash != ""; height-- {
+		block, err := readBlock(s, hash)
+		if err != nil {
+			report.add(nil, "block %s at height %d: %s", hash, height, err)
+			break
+		}
+		reachable[hash] = true
+		blocks = append(blocks, block)
+		if block.Height != height {
+			report.add(block, "height is %d, expected %d", block.Height, height)
+		}
+		if checkHeader(block) != nil {
+			report.add(block, "hash doesn't match content or difficulty")
+		}
+		hash = block.PrevHash
+	}
+	report.CheckedBlocks = len(blocks)
+	if len(blocks) > 0 && blocks[len(blocks)-1].PrevHash == "" && height != 0 {
+		report.add(blocks[len(blocks)-1], "genesis is reached at height %d, expected 1", height+1)
+	}
+
+	if report.PrunedHeight == 0 {
+		verifyTransactions(s, blocks, report)
+	}
+
+	for _, hash := range s.BlockHashes() {
+		if !reachable[hash] {
+			report.Orphans = append(report.Orphans, hash)
+		}
+	}
+	report.OK = len(report.Findings) == 0 && len(report.Orphans) == 0
+	return report
+}
+
+//verifyTransactions replay transactions of blocks from oldest to newest and compare result with indexes of s
+//blocks are ordered from newest to oldest like Blocks
+func verifyTransactions(s db.Store, blocks []*Block, report *VerifyReport) {
+	unspents := make(map[string]*TxOut)
+	tokens := make(map[string]string)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		for _, tx := range block.Transactions {
+			report.CheckedTxs++
+			if string(s.Index(txIndex, tx.ID)) != block.Hash {
+				report.add(block, "tx index doesn't point tx %s at its block", tx.ID)
+			}
+			if tx.Issuance != nil {
+				tokens[tx.Issuance.TokenID()] = tx.ID
+			}
+			ctx := &script.Context{Payload: tx.ID, Height: block.Height, Timestamp: block.Timestamp}
+			for _, txIn := range tx.TxIns {
+				if txIn.isCoinbase() {
+					continue
+				}
+				key := outPoint(txIn.TxID, txIn.Index)
+				prevTxOut, ok := unspents[key]
+				if !ok {
+					report.add(block, "tx %s spends missing or spent output %s", tx.ID, key)
+					continue
+				}
+				if err := script.Run(txIn.Script, prevTxOut.lockingScript(), ctx); err != nil {
+					report.add(block, "tx %s input %s: %s", tx.ID, key, err)
+				}
+				delete(unspents, key)
+			}
+			for index, output := range tx.TxOuts {
+				if !script.IsData(output.Script) {
+					unspents[outPoint(tx.ID, index)] = output
+				}
+			}
+		}
+	}
+
+	indexed := make(map[string]bool)
+	for _, entry := range unspentIn(s) {
+		key := outPoint(entry.TxID, entry.Index)
+		indexed[key] = true
+		output, ok := unspents[key]
+		if !ok {
+			report.add(nil, "utxo index has output %s which is not unspent in blocks", key)
+		} else if !sameTxOut(output, entry.TxOut) {
+			report.add(nil, "utxo index has output %s which differs from blocks", key)
+		}
+	}
+	for key := range unspents {
+		if !indexed[key] {
+			report.add(nil, "utxo index misses unspent output %s", key)
+		}
+	}
+
+	indexedTokens := s.IndexPrefix(tokenIndex, "")
+	for tokenID, txID := range indexedTokens {
+		if tokens[tokenID] != string(txID) {
+			report.add(nil, "token index has token %s which is not issued by tx %s in blocks", tokenID, txID)
+		}
+	}
+	for tokenID := range tokens {
+		if indexedTokens[tokenID] == nil {
+			report.add(nil, "token index misses token %s", tokenID)
+		}
+	}
+}
+
+func sameTxOut(a, b *TxOut) bool {
+	return string(utils.ToBytes(a)) == string(utils.ToBytes(b))
+}
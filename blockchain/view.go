@@ -0,0 +1,72 @@
+package blockchain
+
+import "github.com/Gunyoung-Kim/blockchain/script"
+
+//chainView is state of chain which transactions are verified against
+type chainView interface {
+	//unspent return output of transaction at index if it is not spent
+	unspent(txID string, index int) *TxOut
+	//issued return whether token of ID is issued
+	issued(tokenID string) bool
+	//known return whether transaction of ID is in chain
+	known(txID string) bool
+}
+
+//indexView is state of blockChain in DB, which is read from utxoIndex, tokenIndex and txIndex
+type indexView struct{}
+
+func (indexView) unspent(txID string, index int) *TxOut {
+	return unspentTxOut(txID, index)
+}
+
+func (indexView) issued(tokenID string) bool {
+	return issued(tokenID)
+}
+
+func (indexView) known(txID string) bool {
+	return store().Index(txIndex, txID) != nil
+}
+
+//replayView is state of chain built in memory by connecting its blocks from genesis
+//chain from peer is checked with it before it replaces blockChain
+type replayView struct {
+	unspents map[string]*TxOut
+	tokens   map[string]bool
+	txs      map[string]bool
+}
+
+func newReplayView() *replayView {
+	return &replayView{unspents: make(map[string]*TxOut), tokens: make(map[string]bool), txs: make(map[string]bool)}
+}
+
+func (v *replayView) unspent(txID string, index int) *TxOut {
+	return v.unspents[outPoint(txID, index)]
+}
+
+func (v *replayView) issued(tokenID string) bool {
+	return v.tokens[tokenID]
+}
+
+func (v *replayView) known(txID string) bool {
+	return v.txs[txID]
+}
+
+//connect spend outputs which inputs of block spend, then add transactions, outputs and tokens which block makes
+func (v *replayView) connect(block *Block) {
+	for _, tx := range block.Transactions {
+		v.txs[tx.ID] = true
+		for _, txIn := range tx.TxIns {
+			if !txIn.isCoinbase() {
+				delete(v.unspents, outPoint(txIn.TxID, txIn.Index))
+			}
+		}
+		for index, output := range tx.TxOuts {
+			if !script.IsData(output.Script) {
+				v.unspents[outPoint(tx.ID, index)] = output
+			}
+		}
+		if tx.Issuance != nil {
+			v.tokens[tx.Issuance.TokenID()] = true
+		}
+	}
+}
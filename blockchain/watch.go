@@ -0,0 +1,79 @@
+package blockchain
+
+import (
+	"log"
+	"sync"
+
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+const maxWatchEvents int = 100 // number of recent notifications which node keeps
+
+//WatchEvent is notification that watch-only address receives or spends funds by transaction
+//transaction is notified without confirmation when it enters mempool, then again when its block is connected
+//blocks of chain replaced by peer are not notified
+type WatchEvent struct {
+	Address string `json:"address"`
+	*WalletTx
+}
+
+// watchEvents is recent notifications, oldest first
+// Protected by mutex from data races
+type watchEvents struct {
+	v []*WatchEvent
+	m sync.Mutex
+}
+
+var events watchEvents
+
+func (e *watchEvents) add(event *WatchEvent) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.v = append(e.v, event)
+	if len(e.v) > maxWatchEvents {
+		e.v = e.v[len(e.v)-maxWatchEvents:]
+	}
+}
+
+//WatchEvents return recent notifications of addresses, newest first
+func WatchEvents(addresses []string) []*WatchEvent {
+	watched := make(map[string]bool)
+	for _, address := range addresses {
+		watched[wallet.NormalizeAddress(address)] = true
+	}
+	events.m.Lock()
+	defer events.m.Unlock()
+	result := []*WatchEvent{}
+	for i := len(events.v) - 1; i >= 0; i-- {
+		if watched[events.v[i].Address] {
+			result = append(result, events.v[i])
+		}
+	}
+	return result
+}
+
+//notifyWatchers log and remember notification for each watch-only address of loaded wallets which transactions touch
+//block is nil for transaction entering mempool
+func notifyWatchers(txs []*Tx, block *Block, b *blockChain) {
+	watched := wallet.WatchedAddresses()
+	if len(watched) == 0 {
+		return
+	}
+	for _, tx := range txs {
+		for address := range watched {
+			mine := map[string]bool{address: true}
+			if !touches(tx, mine) {
+				continue
+			}
+			event := &WatchEvent{address, summarizeTx(tx, block, mine, b)}
+			log.Printf("Watch-only address %s: %s transaction %s, amount %d, confirmations %d\n", address, event.Direction, tx.ID, event.Amount, event.Confirmations)
+			events.add(event)
+		}
+	}
+}
+
+//add put transaction in mempool and notify watchers of it
+func (m *mempool) add(tx *Tx) {
+	m.Txs[tx.ID] = tx
+	notifyWatchers([]*Tx{tx}, nil, nil)
+}
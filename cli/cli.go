@@ -3,26 +3,136 @@ package cli
 import (
 	"flag"
 	"fmt"
+	"io"
+	"log"
+	"os"
 	"runtime"
 
+	"github.com/Gunyoung-Kim/blockchain/blockchain"
+	"github.com/Gunyoung-Kim/blockchain/config"
+	"github.com/Gunyoung-Kim/blockchain/db"
 	"github.com/Gunyoung-Kim/blockchain/explorer"
 	"github.com/Gunyoung-Kim/blockchain/rest"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
 )
 
 func usage() {
 	fmt.Printf("Please use the following flags:\n\n")
 	fmt.Printf("-port: 	Set the port of the server\n")
 	fmt.Printf("-mode: 	Choose between 'html' and 'rest' or 'both'\n")
+	fmt.Printf("-maxdata: 	Set maximum bytes of data output\n")
+	fmt.Printf("-memory: 	Keep blockchain, wallets, peer list and logs in memory instead of data directory\n")
+	fmt.Printf("-datadir: 	Set directory for DB, wallet, peer list and logs (default data_<port>)\n")
+	fmt.Printf("-network: 	Set network, data of each network lives in its own subdirectory\n")
+	fmt.Printf("-reindex: 	Rebuild checkpoint and indexes from stored blocks before start\n")
+	fmt.Printf("-prune: 	Keep bodies of only this many newest blocks, 0 keeps all\n")
+	fmt.Printf("-loadsnapshot: 	Bootstrap empty DB from UTXO snapshot file and backfill history from peers\n")
+	fmt.Printf("-snapshotcommitment: 	Refuse UTXO snapshot unless its commitment is this trusted one\n")
+	fmt.Printf("\nWallet file is encrypted with passphrase in %s, which also unlocks wallet at start\n", walletPassphraseEnv)
+	fmt.Printf("\nOr run one of the following commands after flags:\n\n")
+	fmt.Printf("export <file>: 	Write all blocks from genesis to tip as JSON lines\n")
+	fmt.Printf("import <file>: 	Validate and connect blocks written by export\n")
+	fmt.Printf("backup <file>: 	Write consistent copy of DB\n")
+	fmt.Printf("restore <file>: 	Validate copy of DB written by backup and replace DB with it\n")
+	fmt.Printf("verify: 	Check integrity of DB and print report as JSON\n")
+	fmt.Printf("snapshot <file>: 	Write UTXO snapshot at tip with headers of chain and print its commitment\n")
+	fmt.Printf("sign <unsigned file> <wallet file> <signed file>: 	Sign transaction built by /transactions/build with wallet file\n")
 	runtime.Goexit() // for execute defer in main
 }
 
+const (
+	logFileName         string = "node.log"
+	walletPassphraseEnv string = "WALLET_PASSPHRASE" // environment variable of passphrase for wallet file
+)
+
+// setupLog write logs to log file in data directory as well as stderr
+// node keeping its data in memory logs only to stderr
+func setupLog() {
+	if config.Memory() {
+		return
+	}
+	file, err := os.OpenFile(config.Path(logFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Printf("Failed to open log file: %s\n", err)
+		return
+	}
+	log.SetOutput(io.MultiWriter(os.Stderr, file))
+}
+
+// exit print err and exit with non-zero code after closing DB
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	db.Close()
+	os.Exit(1)
+}
+
 //Start CLI
 func Start() {
 	port := flag.Int("port", 4000, "Set Port of this server ")
 	mode := flag.String("mode", "rest", "Choose between 'html' and 'rest' or 'both'")
+	maxData := flag.Int("maxdata", blockchain.MaxDataSize, "Set maximum bytes of data output")
+	memory := flag.Bool("memory", false, "Keep blockchain, wallets, peer list and logs in memory instead of data directory")
+	dataDir := flag.String("datadir", "", "Set directory for DB, wallet, peer list and logs (default data_<port>)")
+	network := flag.String("network", config.DefaultNetwork, "Set network, data of each network lives in its own subdirectory")
+	reindex := flag.Bool("reindex", false, "Rebuild checkpoint and indexes from stored blocks before start")
+	prune := flag.Int("prune", 0, "Keep bodies of only this many newest blocks, 0 keeps all")
+	loadSnapshot := flag.String("loadsnapshot", "", "Bootstrap empty DB from UTXO snapshot file and backfill history from peers")
+	snapshotCommitment := flag.String("snapshotcommitment", "", "Refuse UTXO snapshot unless its commitment is this trusted one")
 
 	flag.Parse()
 
+	if *dataDir == "" {
+		*dataDir = fmt.Sprintf("data_%d", *port)
+	}
+	if *memory {
+		config.UseMemory()
+	}
+	config.Init(*dataDir, *network)
+	if flag.NArg() > 0 && runOfflineCommand(flag.Args()) {
+		return
+	}
+	setupLog()
+
+	blockchain.MaxDataSize = *maxData
+	blockchain.PruneDepth = *prune
+	var store db.Store
+	if *memory {
+		store = db.NewMemoryStore()
+	} else {
+		var err error
+		if store, err = db.Default(); err != nil {
+			exit(err)
+		}
+	}
+	if err := blockchain.UseStore(store); err != nil {
+		exit(err)
+	}
+	if *loadSnapshot != "" {
+		snapshot, err := loadUTXOSnapshot(*loadSnapshot, *snapshotCommitment)
+		if err != nil {
+			exit(err)
+		}
+		log.Printf("Loaded %d unspent outputs at block %s at height %d with commitment %s\n", len(snapshot.Outputs), snapshot.Hash, snapshot.Height, snapshot.Commitment)
+		if *snapshotCommitment == "" {
+			log.Printf("Commitment of snapshot is not checked against trusted one, compare it with commitment published by its maker\n")
+		}
+	}
+	if *reindex {
+		tip, err := blockchain.Reindex()
+		if err != nil {
+			exit(err)
+		}
+		log.Printf("Reindexed blockchain to block %s at height %d\n", tip.Hash, tip.Height)
+	}
+
+	if flag.NArg() > 0 {
+		runCommand(flag.Args())
+		return
+	}
+	if err := wallet.Open(os.Getenv(walletPassphraseEnv)); err != nil {
+		exit(err)
+	}
+
 	switch *mode {
 	case "html":
 		explorer.Start(*port)
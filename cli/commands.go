@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Gunyoung-Kim/blockchain/blockchain"
+	"github.com/Gunyoung-Kim/blockchain/db"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+// command is subcommand of CLI which runs instead of server
+// args is arguments after name of command
+type command func(args []string) error
+
+var commands = map[string]command{
+	"export":   exportChain,
+	"import":   importChain,
+	"backup":   backupChain,
+	"restore":  restoreChain,
+	"verify":   verifyChain,
+	"snapshot": snapshotChain,
+}
+
+// offlineCommands are subcommands which don't use DB, so they run before DB is opened
+// they work while node runs on same data directory
+var offlineCommands = map[string]command{
+	"sign": signTx,
+}
+
+// runCommand run subcommand named args[0] in commands
+func runCommand(args []string) {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage()
+	}
+	if err := cmd(args[1:]); err != nil {
+		exit(err)
+	}
+}
+
+// runOfflineCommand run subcommand named args[0] if it is one of offlineCommands
+// it returns whether it ran
+func runOfflineCommand(args []string) bool {
+	cmd, ok := offlineCommands[args[0]]
+	if !ok {
+		return false
+	}
+	if err := cmd(args[1:]); err != nil {
+		exit(err)
+	}
+	return true
+}
+
+// exportChain write all blocks from genesis to tip to file of args[0]
+func exportChain(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: export <file>")
+	}
+	file, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	chain, err := blockchain.ExistingBlockChain()
+	if err != nil {
+		return err
+	}
+	err = blockchain.Export(chain, file, func(done, total int) {
+		fmt.Printf("\rExported %d/%d blocks", done, total)
+	})
+	fmt.Println()
+	return err
+}
+
+// importChain read blocks from file of args[0] and connect them
+func importChain(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: import <file>")
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = blockchain.Import(file, func(height int) {
+		fmt.Printf("\rImported block %d", height)
+	})
+	fmt.Println()
+	return err
+}
+
+// backupChain write consistent copy of DB to file of args[0]
+func backupChain(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: backup <file>")
+	}
+	file, err := os.OpenFile(args[0], os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	n, err := blockchain.Backup(file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d bytes to %s\n", n, args[0])
+	return file.Sync()
+}
+
+// restoreChain validate snapshot of args[0] then replace DB with it
+func restoreChain(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: restore <file>")
+	}
+	snapshot, err := db.OpenSnapshot(args[0])
+	if err != nil {
+		return err
+	}
+	err = blockchain.ValidateStore(snapshot)
+	snapshot.Close()
+	if err != nil {
+		return err
+	}
+
+	if err := db.Restore(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Restored DB from %s\n", args[0])
+	return nil
+}
+
+// verifyChain audit DB and print report as JSON
+// it returns error if any problem is found, so CLI exits with non-zero code
+func verifyChain(args []string) error {
+	chain, err := blockchain.ExistingBlockChain()
+	if err != nil {
+		return err
+	}
+	report := blockchain.Verify(chain)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	if !report.OK {
+		return errors.New("Chain is corrupted")
+	}
+	return nil
+}
+
+// snapshotChain write UTXO snapshot at tip to file of args[0]
+func snapshotChain(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: snapshot <file>")
+	}
+	file, err := os.OpenFile(args[0], os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	chain, err := blockchain.ExistingBlockChain()
+	if err != nil {
+		return err
+	}
+	snapshot, err := blockchain.WriteUTXOSnapshot(chain, file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote UTXO snapshot to %s\n", args[0])
+	fmt.Printf("Commitment: %s\n", snapshot.Commitment)
+	return file.Sync()
+}
+
+// loadUTXOSnapshot bootstrap empty DB from UTXO snapshot file of path
+// snapshot is refused unless its commitment is trusted, if trusted is given
+func loadUTXOSnapshot(path, trusted string) (*blockchain.UTXOSnapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return blockchain.LoadUTXOSnapshot(file, trusted)
+}
+
+// signTx sign unsigned transaction in file of args[0] with wallet file of args[1]
+// then write signed transaction to file of args[2], it doesn't need blockchain
+// wallet file is decrypted with passphrase in walletPassphraseEnv
+func signTx(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("usage: sign <unsigned file> <wallet file> <signed file>")
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	unsigned := &blockchain.UnsignedTx{}
+	if err := json.Unmarshal(data, unsigned); err != nil {
+		return err
+	}
+	w, err := wallet.OpenFile(args[1], os.Getenv(walletPassphraseEnv))
+	if err != nil {
+		return err
+	}
+	tx, err := blockchain.SignTx(unsigned, w)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(args[2], os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(tx); err != nil {
+		return err
+	}
+	fmt.Printf("Signed transaction %s to %s\n", tx.ID, args[2])
+	return file.Sync()
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/Gunyoung-Kim/blockchain/utils"
+)
+
+const (
+	//DefaultNetwork is network used when no network is given
+	DefaultNetwork string = "mainnet"
+)
+
+var dataDir string // root directory for all data of node
+var network string // name of network, data of each network lives in its own subdirectory
+var memory bool    // whether node keeps wallets, peer list and logs only in memory
+
+//Init set data directory and network of node then create directory for them
+//directory is not created for node which keeps its data in memory
+func Init(dir, net string) {
+	dataDir = dir
+	network = net
+	if memory {
+		return
+	}
+	utils.HandleError(os.MkdirAll(Dir(), 0700))
+}
+
+//UseMemory make node keep wallets, peer list and logs only in memory, so nothing is written into data directory
+//it must be called before Init
+func UseMemory() {
+	memory = true
+}
+
+//Memory return whether node keeps its data only in memory
+func Memory() bool {
+	return memory
+}
+
+//Network return name of network
+func Network() string {
+	if network == "" {
+		return DefaultNetwork
+	}
+	return network
+}
+
+//Dir return directory where data of network lives
+//it is current directory if Init is not called
+func Dir() string {
+	return filepath.Join(dataDir, network)
+}
+
+//Path return path of file named name in directory of network
+func Path(name string) string {
+	return filepath.Join(Dir(), name)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/Gunyoung-Kim/blockchain/config"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	backupSuffix  = ".bak"     // suffix of DB file which is replaced by restore
+	restoreSuffix = ".restore" // suffix of temporary file while restoring
+)
+
+//ErrBackupUnsupported is error returned when Store can't be backed up
+var ErrBackupUnsupported = errors.New("Backup is not supported for this storage")
+
+//ErrInvalidSnapshot is error returned when snapshot file is not DB of blockchain
+var ErrInvalidSnapshot = errors.New("Snapshot is not a blockchain database")
+
+//Backup write consistent copy of DB file to w while DB is in use
+func (s *boltStore) Backup(w io.Writer) (int64, error) {
+	var n int64
+	err := s.db.View(func(t *bolt.Tx) error {
+		var err error
+		n, err = t.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+//OpenSnapshot open DB file of path read-only
+//it returns ErrInvalidSnapshot if file doesn't have buckets of blockchain
+func OpenSnapshot(path string) (Store, error) {
+	db, err := openBoltFile(path, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	err = db.View(func(t *bolt.Tx) error {
+		if t.Bucket([]byte(dataBucket)) == nil || t.Bucket([]byte(blocksBucket)) == nil {
+			return ErrInvalidSnapshot
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltStore{db}, nil
+}
+
+//Restore replace DB file in data directory with snapshot of path
+//default Store is closed first and old DB file is kept with backupSuffix
+//it returns ErrNodeRunning if other process holds DB file
+func Restore(path string) error {
+	Close()
+	target := config.Path(dbName)
+	if _, err := os.Stat(target); err == nil {
+		db, err := openBoltFile(target, &bolt.Options{})
+		if err != nil {
+			return err
+		}
+		db.Close()
+	}
+	if err := copyFile(path, target+restoreSuffix); err != nil {
+		return err
+	}
+	if _, err := os.Stat(target); err == nil {
+		if err := os.Rename(target, target+backupSuffix); err != nil {
+			return err
+		}
+	}
+	return os.Rename(target+restoreSuffix, target)
+}
+
+// copyFile copy file of src to dst and sync it to disk
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
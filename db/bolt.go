@@ -0,0 +1,241 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Gunyoung-Kim/blockchain/utils"
+	bolt "go.etcd.io/bbolt"
+)
+
+const openTimeout = time.Second // time to wait for lock of DB file held by other process
+
+//ErrNodeRunning is error returned when DB file is locked by other process, which is usually running node
+var ErrNodeRunning = errors.New("DB file is locked, stop node using it first")
+
+// openBoltFile open bbolt DB on path, it gives up after openTimeout if other process holds it
+func openBoltFile(path string, options *bolt.Options) (*bolt.DB, error) {
+	options.Timeout = openTimeout
+	db, err := bolt.Open(path, 0600, options)
+	if err == bolt.ErrTimeout {
+		return nil, ErrNodeRunning
+	}
+	return db, err
+}
+
+// boltStore is Store on bbolt file
+type boltStore struct {
+	db *bolt.DB
+}
+
+// boltBatch is Batch on bbolt read-write transaction
+type boltBatch struct {
+	t *bolt.Tx
+}
+
+//OpenBolt open bbolt Store on path
+// create bucket for dataBucket and blocksBucket if not exist
+//it returns ErrNodeRunning if other process holds file of path
+func OpenBolt(path string) (Store, error) {
+	db, err := openBoltFile(path, &bolt.Options{})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(t *bolt.Tx) error {
+		_, err := t.CreateBucketIfNotExists([]byte(dataBucket))
+		utils.HandleError(err)
+		_, err = t.CreateBucketIfNotExists([]byte(blocksBucket))
+		return err
+	})
+	utils.HandleError(err)
+	return &boltStore{db}, nil
+}
+
+//Close database
+func (s *boltStore) Close() {
+	s.db.Close()
+}
+
+//Update run fn in one read-write transaction
+//all writes of fn are rollbacked if fn returns error
+func (s *boltStore) Update(fn func(Batch) error) error {
+	return s.db.Update(func(t *bolt.Tx) error {
+		return fn(&boltBatch{t})
+	})
+}
+
+// update commit single write
+func (s *boltStore) update(fn func(Batch)) {
+	utils.HandleError(s.Update(func(b Batch) error {
+		fn(b)
+		return nil
+	}))
+}
+
+// get read value of key in bucket
+// use transaction for read-only
+func (s *boltStore) get(bucketName, key string) []byte {
+	var data []byte
+	s.db.View(func(t *bolt.Tx) error {
+		bucket := t.Bucket([]byte(bucketName))
+		if bucket == nil {
+			return nil
+		}
+		if value := bucket.Get([]byte(key)); value != nil {
+			data = append([]byte{}, value...)
+		}
+		return nil
+	})
+	return data
+}
+
+// keys read all keys in bucket
+func (s *boltStore) keys(bucketName string) []string {
+	var keys []string
+	s.db.View(func(t *bolt.Tx) error {
+		bucket := t.Bucket([]byte(bucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	return keys
+}
+
+// entries read keys and values in bucket whose keys start with prefix
+// cursor seeks to prefix in one read-only transaction, so only matching keys are visited
+func (s *boltStore) entries(bucketName, prefix string) map[string][]byte {
+	entries := make(map[string][]byte)
+	s.db.View(func(t *bolt.Tx) error {
+		bucket := t.Bucket([]byte(bucketName))
+		if bucket == nil {
+			return nil
+		}
+		cursor := bucket.Cursor()
+		for k, v := cursor.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = cursor.Next() {
+			entries[string(k)] = append([]byte{}, v...)
+		}
+		return nil
+	})
+	return entries
+}
+
+// ------------------- read functions of boltStore --------------
+
+//Block read a Block from DB(blocksBucket) and return slice of byte
+func (s *boltStore) Block(hash string) []byte {
+	return s.get(blocksBucket, hash)
+}
+
+//BlockHashes return hashes of all blocks in blocksBucket
+func (s *boltStore) BlockHashes() []string {
+	return s.keys(blocksBucket)
+}
+
+//CheckPoint read checkPoint from DB(dataBucket) and return slice of byte
+func (s *boltStore) CheckPoint() []byte {
+	return s.get(dataBucket, checkPoint)
+}
+
+//SchemaVersion read version of schema from DB(dataBucket)
+//DB without version is version 0
+func (s *boltStore) SchemaVersion() int {
+	version, err := strconv.Atoi(string(s.get(dataBucket, schemaVersion)))
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+//Index read value of key in index
+func (s *boltStore) Index(name, key string) []byte {
+	return s.get(indexPrefix+name, key)
+}
+
+//IndexKeys return all keys in index
+func (s *boltStore) IndexKeys(name string) []string {
+	return s.keys(indexPrefix + name)
+}
+
+//IndexPrefix return keys and values in index whose keys start with prefix, empty prefix returns whole index
+func (s *boltStore) IndexPrefix(name, prefix string) map[string][]byte {
+	return s.entries(indexPrefix+name, prefix)
+}
+
+// ------------------- write functions of boltStore --------------
+
+func (s *boltStore) SaveBlock(hash string, data []byte) {
+	s.update(func(b Batch) { b.SaveBlock(hash, data) })
+}
+
+func (s *boltStore) DeleteBlock(hash string) {
+	s.update(func(b Batch) { b.DeleteBlock(hash) })
+}
+
+func (s *boltStore) SaveCheckPoint(data []byte) {
+	s.update(func(b Batch) { b.SaveCheckPoint(data) })
+}
+
+func (s *boltStore) SaveSchemaVersion(version int) {
+	s.update(func(b Batch) { b.SaveSchemaVersion(version) })
+}
+
+func (s *boltStore) SaveIndex(name, key string, data []byte) {
+	s.update(func(b Batch) { b.SaveIndex(name, key, data) })
+}
+
+func (s *boltStore) DeleteIndex(name, key string) {
+	s.update(func(b Batch) { b.DeleteIndex(name, key) })
+}
+
+func (s *boltStore) ClearIndex(name string) {
+	s.update(func(b Batch) { b.ClearIndex(name) })
+}
+
+// ------------------- functions of boltBatch --------------
+
+//SaveBlock save a block in blocksBucket
+func (b *boltBatch) SaveBlock(hash string, data []byte) {
+	utils.HandleError(b.t.Bucket([]byte(blocksBucket)).Put([]byte(hash), data))
+}
+
+//DeleteBlock delete a block in blocksBucket
+func (b *boltBatch) DeleteBlock(hash string) {
+	utils.HandleError(b.t.Bucket([]byte(blocksBucket)).Delete([]byte(hash)))
+}
+
+//SaveCheckPoint save checkPoint of blockChain in dataBucket
+func (b *boltBatch) SaveCheckPoint(data []byte) {
+	utils.HandleError(b.t.Bucket([]byte(dataBucket)).Put([]byte(checkPoint), data))
+}
+
+//SaveSchemaVersion save version of schema in dataBucket
+func (b *boltBatch) SaveSchemaVersion(version int) {
+	utils.HandleError(b.t.Bucket([]byte(dataBucket)).Put([]byte(schemaVersion), []byte(strconv.Itoa(version))))
+}
+
+//SaveIndex save value of key in index, bucket of index is created if not exist
+func (b *boltBatch) SaveIndex(name, key string, data []byte) {
+	bucket, err := b.t.CreateBucketIfNotExists([]byte(indexPrefix + name))
+	utils.HandleError(err)
+	utils.HandleError(bucket.Put([]byte(key), data))
+}
+
+//DeleteIndex delete key in index
+func (b *boltBatch) DeleteIndex(name, key string) {
+	if bucket := b.t.Bucket([]byte(indexPrefix + name)); bucket != nil {
+		utils.HandleError(bucket.Delete([]byte(key)))
+	}
+}
+
+//ClearIndex delete all keys in index
+func (b *boltBatch) ClearIndex(name string) {
+	if b.t.Bucket([]byte(indexPrefix+name)) != nil {
+		utils.HandleError(b.t.DeleteBucket([]byte(indexPrefix + name)))
+	}
+}
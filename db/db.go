@@ -1,107 +1,67 @@
 package db
 
 import (
-	"fmt"
-	"os"
+	"io"
+	"sync"
 
-	"github.com/Gunyoung-Kim/blockchain/utils"
-	bolt "go.etcd.io/bbolt"
+	"github.com/Gunyoung-Kim/blockchain/config"
 )
 
-var db *bolt.DB // varaible for singleton pattern of *blot.DB
-
-func getPort() string {
-	port := os.Args[2][6:]
-	return fmt.Sprintf("%s_%s.db", dbName, port)
-}
-
 const (
-	dbName = "blockchain" // DB Name
+	dbName = "blockchain.db" // DB file name in data directory
 
 	dataBucket   = "data"   // Bucket name for checkPoint of blockChain
 	blocksBucket = "blocks" // Bucket name for blocks
+	indexPrefix  = "index_" // Prefix of bucket name for indexes
 
-	checkPoint = "checkPoint" // Key for dataBucket, all data for dataBucket use this key
+	checkPoint    = "checkPoint"    // Key for dataBucket, checkPoint of blockChain
+	schemaVersion = "schemaVersion" // Key for dataBucket, version of schema of DB
 )
 
-//DB return *bolt.DB which is designed by singleton pattern
-// create bucker for dataBucket and blocksBucket if not exist
-func DB() *bolt.DB {
-	if db == nil {
-		dbPointer, err := bolt.Open(dbName, 0600, nil)
-		utils.HandleError(err)
-		db = dbPointer
-		db.Update(func(t *bolt.Tx) error {
-			_, err = t.CreateBucketIfNotExists([]byte(dataBucket))
-			utils.HandleError(err)
-			_, err = t.CreateBucketIfNotExists([]byte(blocksBucket))
-			return err
-		})
-		utils.HandleError(err)
-	}
-
-	return db
+//Batch is set of writes for blocks, checkPoint and indexes
+type Batch interface {
+	SaveBlock(hash string, data []byte)
+	DeleteBlock(hash string)
+	SaveCheckPoint(data []byte)
+	SaveSchemaVersion(version int)
+	SaveIndex(name, key string, data []byte)
+	DeleteIndex(name, key string)
+	ClearIndex(name string)
 }
 
-//Close database
-func Close() {
-	DB().Close()
+//Store is storage of blockchain
+//writes of Store itself are committed one by one,
+//writes of Batch given by Update are committed at once or not at all
+type Store interface {
+	Batch
+	Block(hash string) []byte
+	BlockHashes() []string
+	CheckPoint() []byte
+	SchemaVersion() int
+	Index(name, key string) []byte
+	IndexKeys(name string) []string
+	IndexPrefix(name, prefix string) map[string][]byte
+	Update(fn func(Batch) error) error
+	Backup(w io.Writer) (int64, error)
+	Close()
 }
 
-// ------------------- functions for dataBucket --------------
+var defaultStore Store // varaible for singleton pattern of default Store
+var defaultErr error   // error of opening default Store
+var once sync.Once
 
-//CheckPoint read checkPoint from DB(dataBucket) and return slice of byte
-//use transaction for read-only
-func CheckPoint() []byte {
-	var data []byte
-	DB().View(func(t *bolt.Tx) error {
-		bucket := t.Bucket([]byte(dataBucket))
-		data = bucket.Get([]byte(checkPoint))
-		return nil
+//Default return bbolt Store on dbName in data directory which is designed by singleton pattern
+//error of opening it is returned every time, ErrNodeRunning if other process holds it
+func Default() (Store, error) {
+	once.Do(func() {
+		defaultStore, defaultErr = OpenBolt(config.Path(dbName))
 	})
-	return data
+	return defaultStore, defaultErr
 }
 
-//SaveCheckPoint save checkPoint of blockChain in dataBucket
-func SaveCheckPoint(data []byte) {
-	err := DB().Update(func(t *bolt.Tx) error {
-		bucket := t.Bucket([]byte(dataBucket))
-		err := bucket.Put([]byte(checkPoint), data)
-		return err
-	})
-	utils.HandleError(err)
-}
-
-// ------------------- functions for blocksBucket --------------
-
-//Block read a Block from DB(blocksBucket) and return slice of byte
-//use transaction for read-only
-func Block(hash string) []byte {
-	var data []byte
-	DB().View(func(t *bolt.Tx) error {
-		bucket := t.Bucket([]byte(blocksBucket))
-		data = bucket.Get([]byte(hash))
-		return nil
-	})
-	return data
-}
-
-//SaveBlock save a block in blocksBucket
-func SaveBlock(hash string, data []byte) {
-	err := DB().Update(func(t *bolt.Tx) error {
-		bucket := t.Bucket([]byte(blocksBucket))
-		err := bucket.Put([]byte(hash), data)
-		return err
-	})
-	utils.HandleError(err)
-}
-
-//EmptyBlocks clear blocksBucket in DB
-func EmptyBlocks() {
-	DB().Update(func(t *bolt.Tx) error {
-		utils.HandleError(t.DeleteBucket([]byte(blocksBucket)))
-		_, err := t.CreateBucket([]byte(blocksBucket))
-		utils.HandleError(err)
-		return nil
-	})
+//Close default Store if it is opened
+func Close() {
+	if defaultStore != nil {
+		defaultStore.Close()
+	}
 }
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexPrefix(t *testing.T) {
+	bolt, err := OpenBolt(filepath.Join(t.TempDir(), dbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	for name, s := range map[string]Store{"memory": NewMemoryStore(), "bolt": bolt} {
+		t.Run(name, func(t *testing.T) {
+			if entries := s.IndexPrefix("address", "a/"); len(entries) != 0 {
+				t.Errorf("missing index has entries %v", entries)
+			}
+			for _, key := range []string{"a/1", "a/2", "ab/1", "b/1"} {
+				s.SaveIndex("address", key, []byte(key))
+			}
+
+			entries := s.IndexPrefix("address", "a/")
+			if len(entries) != 2 || string(entries["a/1"]) != "a/1" || string(entries["a/2"]) != "a/2" {
+				t.Errorf("IndexPrefix(a/) = %v, want a/1 and a/2", entries)
+			}
+			if entries := s.IndexPrefix("address", ""); len(entries) != 4 {
+				t.Errorf("IndexPrefix of empty prefix has %d entries, want 4", len(entries))
+			}
+			if entries := s.IndexPrefix("address", "c"); len(entries) != 0 {
+				t.Errorf("IndexPrefix(c) = %v, want none", entries)
+			}
+		})
+	}
+}
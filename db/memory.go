@@ -0,0 +1,184 @@
+package db
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// memoryStore is Store on memory, all data disappears when process ends
+type memoryStore struct {
+	blocks     map[string][]byte
+	checkPoint []byte
+	version    int
+	indexes    map[string]map[string][]byte
+	m          sync.RWMutex
+}
+
+// memoryBatch collects writes and apply them to memoryStore at once
+// writes are not visible to reads until Update returns
+type memoryBatch struct {
+	writes []func(s *memoryStore)
+}
+
+//NewMemoryStore return empty Store on memory
+func NewMemoryStore() Store {
+	return &memoryStore{
+		blocks:  make(map[string][]byte),
+		indexes: make(map[string]map[string][]byte),
+	}
+}
+
+//Close do nothing for memoryStore
+func (s *memoryStore) Close() {}
+
+//Backup is not supported for memoryStore because there is no file to copy
+func (s *memoryStore) Backup(w io.Writer) (int64, error) {
+	return 0, ErrBackupUnsupported
+}
+
+//Update run fn and apply all writes of fn at once
+//no writes are applied if fn returns error
+func (s *memoryStore) Update(fn func(Batch) error) error {
+	batch := &memoryBatch{}
+	if err := fn(batch); err != nil {
+		return err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, write := range batch.writes {
+		write(s)
+	}
+	return nil
+}
+
+// update commit single write
+func (s *memoryStore) update(fn func(Batch)) {
+	s.Update(func(b Batch) error {
+		fn(b)
+		return nil
+	})
+}
+
+// ------------------- read functions of memoryStore --------------
+
+func (s *memoryStore) Block(hash string) []byte {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.blocks[hash]
+}
+
+func (s *memoryStore) BlockHashes() []string {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	var hashes []string
+	for hash := range s.blocks {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+func (s *memoryStore) CheckPoint() []byte {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.checkPoint
+}
+
+func (s *memoryStore) SchemaVersion() int {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.version
+}
+
+func (s *memoryStore) Index(name, key string) []byte {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.indexes[name][key]
+}
+
+func (s *memoryStore) IndexKeys(name string) []string {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	var keys []string
+	for key := range s.indexes[name] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *memoryStore) IndexPrefix(name, prefix string) map[string][]byte {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	entries := make(map[string][]byte)
+	for key, data := range s.indexes[name] {
+		if strings.HasPrefix(key, prefix) {
+			entries[key] = data
+		}
+	}
+	return entries
+}
+
+// ------------------- write functions of memoryStore --------------
+
+func (s *memoryStore) SaveBlock(hash string, data []byte) {
+	s.update(func(b Batch) { b.SaveBlock(hash, data) })
+}
+
+func (s *memoryStore) DeleteBlock(hash string) {
+	s.update(func(b Batch) { b.DeleteBlock(hash) })
+}
+
+func (s *memoryStore) SaveCheckPoint(data []byte) {
+	s.update(func(b Batch) { b.SaveCheckPoint(data) })
+}
+
+func (s *memoryStore) SaveSchemaVersion(version int) {
+	s.update(func(b Batch) { b.SaveSchemaVersion(version) })
+}
+
+func (s *memoryStore) SaveIndex(name, key string, data []byte) {
+	s.update(func(b Batch) { b.SaveIndex(name, key, data) })
+}
+
+func (s *memoryStore) DeleteIndex(name, key string) {
+	s.update(func(b Batch) { b.DeleteIndex(name, key) })
+}
+
+func (s *memoryStore) ClearIndex(name string) {
+	s.update(func(b Batch) { b.ClearIndex(name) })
+}
+
+// ------------------- functions of memoryBatch --------------
+
+func (b *memoryBatch) SaveBlock(hash string, data []byte) {
+	b.writes = append(b.writes, func(s *memoryStore) { s.blocks[hash] = data })
+}
+
+func (b *memoryBatch) DeleteBlock(hash string) {
+	b.writes = append(b.writes, func(s *memoryStore) { delete(s.blocks, hash) })
+}
+
+func (b *memoryBatch) SaveCheckPoint(data []byte) {
+	b.writes = append(b.writes, func(s *memoryStore) { s.checkPoint = data })
+}
+
+func (b *memoryBatch) SaveSchemaVersion(version int) {
+	b.writes = append(b.writes, func(s *memoryStore) { s.version = version })
+}
+
+func (b *memoryBatch) SaveIndex(name, key string, data []byte) {
+	b.writes = append(b.writes, func(s *memoryStore) {
+		if s.indexes[name] == nil {
+			s.indexes[name] = make(map[string][]byte)
+		}
+		s.indexes[name][key] = data
+	})
+}
+
+func (b *memoryBatch) DeleteIndex(name, key string) {
+	b.writes = append(b.writes, func(s *memoryStore) { delete(s.indexes[name], key) })
+}
+
+func (b *memoryBatch) ClearIndex(name string) {
+	b.writes = append(b.writes, func(s *memoryStore) { delete(s.indexes, name) })
+}
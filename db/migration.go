@@ -0,0 +1,66 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+//ErrSchemaTooNew is error returned when schema of DB is newer than this binary knows
+var ErrSchemaTooNew = errors.New("Database schema is newer than this binary")
+
+//Migration is step which upgrades schema of DB to Version
+//Up reads old data from Store and writes upgraded data to Batch
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(s Store, batch Batch) error
+}
+
+var migrations []Migration // registered migrations ordered by Version
+
+//RegisterMigration add migration which upgrades schema to version
+//migrations must be registered in order of version from 1 without gap
+func RegisterMigration(version int, name string, up func(s Store, batch Batch) error) {
+	if version != len(migrations)+1 {
+		log.Panicf("Migration %q has version %d, expected %d", name, version, len(migrations)+1)
+	}
+	migrations = append(migrations, Migration{version, name, up})
+}
+
+//LatestSchemaVersion return version of schema which this binary writes
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+//Migrate run migrations newer than schema version of s in order
+//each migration and new schema version are committed in one batch
+//empty Store is just stamped with latest version because there is nothing to upgrade
+func Migrate(s Store) error {
+	current := s.SchemaVersion()
+	latest := LatestSchemaVersion()
+	if current > latest {
+		return fmt.Errorf("%w: database is version %d, binary supports up to %d", ErrSchemaTooNew, current, latest)
+	}
+	if s.CheckPoint() == nil && len(s.BlockHashes()) == 0 {
+		if current != latest {
+			s.SaveSchemaVersion(latest)
+		}
+		return nil
+	}
+
+	for _, migration := range migrations[current:] {
+		log.Printf("Migrating database to version %d: %s\n", migration.Version, migration.Name)
+		err := s.Update(func(batch Batch) error {
+			if err := migration.Up(s, batch); err != nil {
+				return err
+			}
+			batch.SaveSchemaVersion(migration.Version)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("migration to version %d failed: %w", migration.Version, err)
+		}
+	}
+	return nil
+}
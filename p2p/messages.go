@@ -2,6 +2,7 @@ package p2p
 
 import (
 	"encoding/json"
+	"log"
 	"strings"
 
 	"github.com/Gunyoung-Kim/blockchain/blockchain"
@@ -76,30 +77,54 @@ func handleMsg(m *Message, p *peer) {
 		utils.HandleError(err)
 
 		if payload.Height >= b.Height {
+			if p.pruned {
+				log.Printf("Peer %s is pruned, can't request all blocks\n", p.key)
+				return
+			}
 			requestAllBlocks(p)
 		} else {
 			sendNewestBlock(p)
 		}
 	case MessageAllBlocksRequest:
+		if pruned() {
+			log.Printf("Refused to send all blocks to %s: blocks are pruned\n", p.key)
+			return
+		}
 		sendAllBlocks(p)
 	case MessageAllBlocksResponse:
 		var payload []*blockchain.Block
 		json.Unmarshal(m.Payload, &payload)
 		utils.HandleError(json.Unmarshal(m.Payload, &payload))
-		blockchain.BlockChain().Replace(payload)
+		if blockchain.Backfilling() {
+			if err := blockchain.Backfill(payload); err != nil {
+				log.Printf("Failed to backfill blocks from %s: %s\n", p.key, err)
+			} else {
+				log.Printf("Backfilled blocks before snapshot from %s\n", p.key)
+			}
+			return
+		}
+		if err := blockchain.BlockChain().Replace(payload); err != nil {
+			log.Printf("Rejected blocks from %s: %s\n", p.key, err)
+		}
 	case MessageNewBlockNotify:
 		var payload *blockchain.Block
 		utils.HandleError(json.Unmarshal(m.Payload, &payload))
-		blockchain.BlockChain().AddPeerBlock(payload)
+		if err := blockchain.BlockChain().AddPeerBlock(payload); err != nil {
+			log.Printf("Rejected block from %s: %s\n", p.key, err)
+		}
 	case MessageNewTxNotify:
 		var payload *blockchain.Tx
 		utils.HandleError(json.Unmarshal(m.Payload, &payload))
-		blockchain.Mempool().AddPeerTx(payload)
+		if err := blockchain.Mempool().AddPeerTx(payload); err != nil {
+			log.Printf("Rejected transaction from %s: %s\n", p.key, err)
+		}
 	case MessageNewPeerNotify:
 		var payload string
 		utils.HandleError(json.Unmarshal(m.Payload, &payload))
 		parts := strings.Split(payload, ":")
-		AddPeer(parts[0], parts[1], parts[2], false)
+		if err := AddPeer(parts[0], parts[1], parts[2], false); err != nil {
+			log.Printf("Failed to connect to peer %s:%s: %s\n", parts[0], parts[1], err)
+		}
 	}
 
 }
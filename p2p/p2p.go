@@ -2,7 +2,9 @@ package p2p
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/Gunyoung-Kim/blockchain/blockchain"
 	"github.com/Gunyoung-Kim/blockchain/utils"
@@ -11,6 +13,16 @@ import (
 
 var upgrader = websocket.Upgrader{}
 
+const (
+	prunedParam  string = "pruned"   // query parameter which dialing peer advertises pruning with
+	prunedHeader string = "X-Pruned" // response header which upgrading peer advertises pruning with
+)
+
+//pruned return whether this node deleted bodies of old blocks
+func pruned() bool {
+	return blockchain.PrunedHeight() > 0
+}
+
 //Upgrade turn http/https connection into web socket connection
 func Upgrade(rw http.ResponseWriter, req *http.Request) {
 	// Port :3000 will upgrade the request from :4000
@@ -19,22 +31,45 @@ func Upgrade(rw http.ResponseWriter, req *http.Request) {
 	upgrader.CheckOrigin = func(r *http.Request) bool {
 		return openPort != "" && ip != ""
 	}
-	conn, err := upgrader.Upgrade(rw, req, nil)
+	header := http.Header{}
+	header.Set(prunedHeader, strconv.FormatBool(pruned()))
+	conn, err := upgrader.Upgrade(rw, req, header)
 	utils.HandleError(err)
-	initPeer(conn, ip, openPort)
+	peerPruned, _ := strconv.ParseBool(req.URL.Query().Get(prunedParam))
+	initPeer(conn, ip, openPort, peerPruned)
 }
 
-func AddPeer(address, port, openPort string, broadcast bool) {
+//AddPeer connect to peer on address:port and remember it in peer list
+//it returns error if connection fails
+func AddPeer(address, port, openPort string, broadcast bool) error {
 	// Port :4000 is request an upgrade from the port :3000
-	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s:%s/ws?openPort=%s", address, port, openPort), nil)
-	utils.HandleError(err)
-	p := initPeer(conn, address, port)
+	conn, res, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s:%s/ws?openPort=%s&%s=%t", address, port, openPort, prunedParam, pruned()), nil)
+	if err != nil {
+		return err
+	}
+	peerPruned, _ := strconv.ParseBool(res.Header.Get(prunedHeader))
+	p := initPeer(conn, address, port, peerPruned)
+	rememberPeer(p.key)
 	if broadcast {
 		BroadcastNewPeer(p)
-		return
+		return nil
 	}
 	sendNewestBlock(p)
+	if blockchain.Backfilling() && !p.pruned {
+		requestAllBlocks(p)
+	}
+	return nil
+}
 
+//ConnectKnownPeers connect to all peers in peer list
+//peers which can't be connected are skipped
+func ConnectKnownPeers(openPort string) {
+	for _, key := range knownPeers() {
+		address, port := utils.Splitter(key, ":", 0), utils.Splitter(key, ":", 1)
+		if err := AddPeer(address, port, openPort, false); err != nil {
+			log.Printf("Failed to connect to known peer %s: %s\n", key, err)
+		}
+	}
 }
 
 func BroadcastNewBlock(b *blockchain.Block) {
@@ -1,12 +1,20 @@
 package p2p
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"sync"
 
+	"github.com/Gunyoung-Kim/blockchain/config"
+	"github.com/Gunyoung-Kim/blockchain/utils"
 	"github.com/gorilla/websocket"
 )
 
+const (
+	peersFileName string = "peers.json"
+)
+
 // Protected by mutex from data races
 type peers struct {
 	v map[string]*peer
@@ -23,6 +31,7 @@ type peer struct {
 	port    string
 	conn    *websocket.Conn
 	inbox   chan []byte
+	pruned  bool // peer can't serve bodies of old blocks
 }
 
 func AllPeers(p *peers) []string {
@@ -65,7 +74,9 @@ func (p *peer) write() {
 	}
 }
 
-func initPeer(conn *websocket.Conn, address, port string) *peer {
+//initPeer register peer of connection and start reading and writing messages with it
+//pruned is pruning status which peer advertised in handshake, it is set before messages are handled
+func initPeer(conn *websocket.Conn, address, port string, pruned bool) *peer {
 	Peers.m.Lock()
 	defer Peers.m.Unlock()
 	key := fmt.Sprintf("%s:%s", address, port)
@@ -75,9 +86,39 @@ func initPeer(conn *websocket.Conn, address, port string) *peer {
 		port:    port,
 		conn:    conn,
 		inbox:   make(chan []byte),
+		pruned:  pruned,
 	}
 	go p.read()
 	go p.write()
 	Peers.v[key] = p
 	return p
 }
+
+// knownPeers return keys of peers in peer list file of data directory
+func knownPeers() []string {
+	var keys []string
+	data, err := os.ReadFile(config.Path(peersFileName))
+	if err != nil {
+		return keys
+	}
+	utils.HandleError(json.Unmarshal(data, &keys))
+	return keys
+}
+
+// rememberPeer add key of peer to peer list file if it isn't there
+// node keeping its data in memory has no peer list file
+func rememberPeer(key string) {
+	if config.Memory() {
+		return
+	}
+	Peers.m.Lock()
+	defer Peers.m.Unlock()
+	keys := knownPeers()
+	for _, known := range keys {
+		if known == key {
+			return
+		}
+	}
+	keys = append(keys, key)
+	utils.HandleError(os.WriteFile(config.Path(peersFileName), utils.ToJSON(keys), 0600))
+}
@@ -1,13 +1,19 @@
 package rest
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/Gunyoung-Kim/blockchain/blockchain"
 	"github.com/Gunyoung-Kim/blockchain/p2p"
+	"github.com/Gunyoung-Kim/blockchain/script"
 	"github.com/Gunyoung-Kim/blockchain/utils"
 	"github.com/Gunyoung-Kim/blockchain/wallet"
 	"github.com/gorilla/mux"
@@ -33,22 +39,140 @@ type urlDescription struct {
 // balanceResponse is response entity for balance
 type balanceResponse struct {
 	Address string `json:"address"`
+	Token   string `json:"token,omitempty"`
 	Balance int    `json:"balance"`
 }
 
 // myWalletResponse is reponse entity for wallet status
+// Mnemonic is only filled when HD wallet is created
 type myWalletResponse struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Locked   bool   `json:"locked"`
+	Mnemonic string `json:"mnemonic,omitempty"`
+}
+
+// newAddressResponse is response entity for new receive address
+type newAddressResponse struct {
 	Address string `json:"address"`
 }
 
+// mnemonicResponse is response entity for mnemonic backup
+type mnemonicResponse struct {
+	Mnemonic string `json:"mnemonic"`
+}
+
 // errorResponse is reponse entity for error message
 type errorResponse struct {
 	ErrorMessage string `json:"errorMessage"`
 }
 
+// addTxPayload is request entity for transaction
+// From is name of wallet to send from, empty From means default wallet
+// Strategy is name of coin selection strategy, Inputs are outputs to spend chosen by hand instead of it
 type addTxPayload struct {
-	To     string
-	Amount int
+	From     string
+	To       string
+	Amount   int
+	Token    string
+	Strategy string
+	Inputs   []blockchain.OutPoint
+}
+
+// rawTxResponse is response entity for accepted raw transaction
+type rawTxResponse struct {
+	TxID string `json:"txID"`
+}
+
+// buildTxPayload is request entity for unsigned transaction
+// From is address which pays, not name of wallet
+type buildTxPayload struct {
+	From     string                `json:"from"`
+	To       string                `json:"to"`
+	Amount   int                   `json:"amount"`
+	Token    string                `json:"token"`
+	Strategy string                `json:"strategy"`
+	Inputs   []blockchain.OutPoint `json:"inputs"`
+}
+
+// createWalletPayload is request entity for creating or loading named wallet
+// if Mnemonic is given, then wallet is restored from it
+type createWalletPayload struct {
+	Name       string `json:"name"`
+	Passphrase string `json:"passphrase"`
+	Mnemonic   string `json:"mnemonic,omitempty"`
+}
+
+type issueTokenPayload struct {
+	Ticker string `json:"ticker"`
+	Supply int    `json:"supply"`
+}
+
+// issueTokenResponse is response entity for token issuance
+type issueTokenResponse struct {
+	TokenID string `json:"tokenID"`
+	TxID    string `json:"txID"`
+}
+
+// addDataPayload is request entity for data output
+// From is name of wallet which pays fee, empty From means default wallet
+type addDataPayload struct {
+	From string `json:"from,omitempty"`
+	Data string `json:"data"`
+	Fee  int    `json:"fee"`
+}
+
+// addHTLCPayload is request entity for hash time-locked contract
+// From is name of wallet which pays and can refund, empty From means default wallet
+type addHTLCPayload struct {
+	From    string `json:"from,omitempty"`
+	To      string `json:"to"`
+	Amount  int    `json:"amount"`
+	Hash    string `json:"hash,omitempty"`
+	Timeout int    `json:"timeout"`
+}
+
+type spendHTLCPayload struct {
+	TxID     string `json:"txID"`
+	Index    int    `json:"index"`
+	Preimage string `json:"preimage,omitempty"`
+}
+
+// htlcResponse is response entity for hash time-locked contract
+// Preimage is only filled when it is made by this node
+type htlcResponse struct {
+	TxID     string `json:"txID"`
+	Index    int    `json:"index"`
+	Hash     string `json:"hash"`
+	Preimage string `json:"preimage,omitempty"`
+}
+
+// labelPayload is request entity for label of transaction or address
+// one of TxID and Address is labeled, empty Label removes label
+type labelPayload struct {
+	TxID    string `json:"txID,omitempty"`
+	Address string `json:"address,omitempty"`
+	Label   string `json:"label"`
+}
+
+// watchPayload is request entity for registering watch-only addresses
+type watchPayload struct {
+	Addresses []string `json:"addresses"`
+}
+
+// watchResponse is response entity for watch-only addresses of wallet with their balances
+// Balance is total of balances of Addresses
+type watchResponse struct {
+	Addresses []balanceResponse `json:"addresses"`
+	Token     string            `json:"token,omitempty"`
+	Balance   int               `json:"balance"`
+}
+
+// unlockWalletPayload is request entity for unlocking wallet
+// Timeout is seconds until wallet is locked again, zero keeps it unlocked until lock
+type unlockWalletPayload struct {
+	Passphrase string `json:"passphrase"`
+	Timeout    int    `json:"timeout"`
 }
 
 type addPeerPayLoad struct {
@@ -90,6 +214,224 @@ func documentation(rw http.ResponseWriter, req *http.Request) {
 			Method:      "GET",
 			Description: "Get TxOuts for an address",
 		},
+		{
+			URL:         url("/tokens"),
+			Method:      "GET",
+			Description: "See all issued tokens",
+		},
+		{
+			URL:         url("/tokens"),
+			Method:      "POST",
+			Description: "Issue a token",
+			Payload:     "ticker:string, supply:int",
+		},
+		{
+			URL:         url("/transactions/data"),
+			Method:      "POST",
+			Description: "Anchor data into unspendable output",
+			Payload:     "from:string(optional), data:string(hex), fee:int",
+		},
+		{
+			URL:         url("/transactions/build"),
+			Method:      "POST",
+			Description: "Build unsigned transaction with previous outputs for signing out of node",
+			Payload:     "from:string, to:string, amount:int, token:string(optional), strategy:string(optional), inputs:[{txID, index}](optional)",
+		},
+		{
+			URL:         url("/transactions/raw"),
+			Method:      "POST",
+			Description: "Validate and broadcast transaction signed out of node, rejection has code and reason",
+			Payload:     "transaction as JSON or its canonical encoding(hex)",
+		},
+		{
+			URL:         url("/anchors/{hash}"),
+			Method:      "GET",
+			Description: "See anchors of data hash",
+		},
+		{
+			URL:         url("/htlc"),
+			Method:      "POST",
+			Description: "Lock coins into hash time-locked contract",
+			Payload:     "from:string(optional), to:string, amount:int, hash:string(optional), timeout:int",
+		},
+		{
+			URL:         url("/htlc/claim"),
+			Method:      "POST",
+			Description: "Claim hash time-locked contract with preimage",
+			Payload:     "txID:string, index:int, preimage:string",
+		},
+		{
+			URL:         url("/htlc/refund"),
+			Method:      "POST",
+			Description: "Refund hash time-locked contract after timeout",
+			Payload:     "txID:string, index:int",
+		},
+		{
+			URL:         url("/wallet"),
+			Method:      "GET",
+			Description: "See address of wallet and whether it is locked",
+		},
+		{
+			URL:         url("/wallet/unlock"),
+			Method:      "POST",
+			Description: "Unlock wallet for signing",
+			Payload:     "passphrase:string, timeout:int(seconds)",
+		},
+		{
+			URL:         url("/wallet/lock"),
+			Method:      "POST",
+			Description: "Lock wallet",
+		},
+		{
+			URL:         url("/wallet/newaddress"),
+			Method:      "GET",
+			Description: "Get a fresh receive address of HD wallet",
+		},
+		{
+			URL:         url("/wallet/rescan"),
+			Method:      "POST",
+			Description: "Restore used addresses of HD wallet up to gap limit",
+		},
+		{
+			URL:         url("/wallet/mnemonic"),
+			Method:      "POST",
+			Description: "See mnemonic of HD wallet for backup",
+			Payload:     "passphrase:string",
+		},
+		{
+			URL:         url("/wallet/transactions"),
+			Method:      "GET",
+			Description: "See incoming and outgoing transactions of wallet",
+		},
+		{
+			URL:         url("/wallet/labels"),
+			Method:      "GET",
+			Description: "See labels of transactions and addresses of wallet",
+		},
+		{
+			URL:         url("/wallet/labels"),
+			Method:      "POST",
+			Description: "Label a transaction or an address, empty label removes it",
+			Payload:     "txID:string or address:string, label:string",
+		},
+		{
+			URL:         url("/wallet/watch"),
+			Method:      "GET",
+			Description: "See watch-only addresses of wallet with their balances",
+		},
+		{
+			URL:         url("/wallet/watch"),
+			Method:      "POST",
+			Description: "Watch addresses without their keys in wallet",
+			Payload:     "addresses:[string]",
+		},
+		{
+			URL:         url("/wallet/watch/transactions"),
+			Method:      "GET",
+			Description: "See transactions of watch-only addresses of wallet",
+		},
+		{
+			URL:         url("/wallet/watch/events"),
+			Method:      "GET",
+			Description: "See recent notifications that watch-only addresses of wallet receive or spend funds",
+		},
+		{
+			URL:         url("/wallets"),
+			Method:      "GET",
+			Description: "See all wallets of node",
+		},
+		{
+			URL:         url("/wallets"),
+			Method:      "POST",
+			Description: "Create a named HD wallet, restored from mnemonic if it is given",
+			Payload:     "name:string, passphrase:string, mnemonic:string(optional)",
+		},
+		{
+			URL:         url("/wallets/{name}/load"),
+			Method:      "POST",
+			Description: "Load a named wallet, unlocked if passphrase is given",
+			Payload:     "passphrase:string(optional)",
+		},
+		{
+			URL:         url("/wallets/{name}/unlock"),
+			Method:      "POST",
+			Description: "Unlock a named wallet for signing",
+			Payload:     "passphrase:string, timeout:int(seconds)",
+		},
+		{
+			URL:         url("/wallets/{name}/lock"),
+			Method:      "POST",
+			Description: "Lock a named wallet",
+		},
+		{
+			URL:         url("/wallets/{name}/newaddress"),
+			Method:      "GET",
+			Description: "Get a fresh receive address of a named HD wallet",
+		},
+		{
+			URL:         url("/wallets/{name}/rescan"),
+			Method:      "POST",
+			Description: "Restore used addresses of a named HD wallet up to gap limit",
+		},
+		{
+			URL:         url("/wallets/{name}/mnemonic"),
+			Method:      "POST",
+			Description: "See mnemonic of a named HD wallet for backup",
+			Payload:     "passphrase:string",
+		},
+		{
+			URL:         url("/wallets/{name}/balance"),
+			Method:      "GET",
+			Description: "See balance of a named wallet",
+		},
+		{
+			URL:         url("/wallets/{name}/transactions"),
+			Method:      "GET",
+			Description: "See incoming and outgoing transactions of a named wallet",
+		},
+		{
+			URL:         url("/wallets/{name}/labels"),
+			Method:      "GET",
+			Description: "See labels of transactions and addresses of a named wallet",
+		},
+		{
+			URL:         url("/wallets/{name}/labels"),
+			Method:      "POST",
+			Description: "Label a transaction or an address in a named wallet, empty label removes it",
+			Payload:     "txID:string or address:string, label:string",
+		},
+		{
+			URL:         url("/wallets/{name}/watch"),
+			Method:      "GET",
+			Description: "See watch-only addresses of a named wallet with their balances",
+		},
+		{
+			URL:         url("/wallets/{name}/watch"),
+			Method:      "POST",
+			Description: "Watch addresses without their keys in a named wallet",
+			Payload:     "addresses:[string]",
+		},
+		{
+			URL:         url("/wallets/{name}/watch/transactions"),
+			Method:      "GET",
+			Description: "See transactions of watch-only addresses of a named wallet",
+		},
+		{
+			URL:         url("/wallets/{name}/watch/events"),
+			Method:      "GET",
+			Description: "See recent notifications that watch-only addresses of a named wallet receive or spend funds",
+		},
+		{
+			URL:         url("/wallets/{name}/send"),
+			Method:      "POST",
+			Description: "Send coins or token from a named wallet",
+			Payload:     "to:string, amount:int, token:string(optional), strategy:string(optional), inputs:[{txID, index}](optional)",
+		},
+		{
+			URL:         url("/admin/backup"),
+			Method:      "GET",
+			Description: "Download consistent copy of DB, only from localhost",
+		},
 		{
 			URL:         url("/ws"),
 			Method:      "GET",
@@ -133,20 +475,70 @@ func block(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// decodePayload decode JSON body of request into payload and return whether it succeeds
+// if body is malformed, then it write errorMsg with status BadRequest
+func decodePayload(rw http.ResponseWriter, req *http.Request, payload interface{}) bool {
+	if err := json.NewDecoder(req.Body).Decode(payload); err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// validAddress return whether address is well-formed address of network of node
+// if it is not, then it write errorMsg with status BadRequest
+func validAddress(rw http.ResponseWriter, address string) bool {
+	if err := wallet.ValidateAddress(address); err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
 // balance return current balance of address
 // if request query contains total, then it returns amount of balance
 // if it doesn't contain, then return list of unused transaction output
+// if request query contains token, then it returns balance of that token instead of coin
 func balance(rw http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	address := vars["address"]
+	if !validAddress(rw, address) {
+		return
+	}
 	isTotal := req.URL.Query().Get("total")
+	token := req.URL.Query().Get("token")
 
 	if isTotal == "true" {
-		amount := blockchain.BalanceByAddress(address, blockchain.BlockChain())
-		balanceRes := balanceResponse{Address: address, Balance: amount}
+		amount := blockchain.TokenBalanceByAddress(address, token, blockchain.BlockChain())
+		balanceRes := balanceResponse{Address: address, Token: token, Balance: amount}
 		utils.HandleError(json.NewEncoder(rw).Encode(balanceRes))
 	} else {
-		utils.HandleError(json.NewEncoder(rw).Encode(blockchain.UTxOutsByAddress(address, blockchain.BlockChain())))
+		utils.HandleError(json.NewEncoder(rw).Encode(blockchain.TokenUTxOutsByAddress(address, token, blockchain.BlockChain())))
+	}
+}
+
+// tokens take two methods
+// if request's method is GET, then return all tokens issued in blockChain
+// if request's method is POST, then add new transaction which issues token in Mempool
+func tokens(rw http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "GET":
+		utils.HandleError(json.NewEncoder(rw).Encode(blockchain.Tokens(blockchain.BlockChain())))
+	case "POST":
+		var payload issueTokenPayload
+		if !decodePayload(rw, req, &payload) {
+			return
+		}
+		tx, err := blockchain.Mempool().IssueToken(payload.Ticker, payload.Supply)
+		if err != nil {
+			writeError(rw, http.StatusBadRequest, err)
+			return
+		}
+
+		p2p.BroadcastNewTx(tx)
+
+		rw.WriteHeader(http.StatusCreated)
+		json.NewEncoder(rw).Encode(issueTokenResponse{TokenID: tx.Issuance.TokenID(), TxID: tx.ID})
 	}
 }
 
@@ -160,23 +552,485 @@ func mempool(rw http.ResponseWriter, req *http.Request) {
 // if there comes error while creaing transaction, then it return errorMsg with status BadRequest
 func transactions(rw http.ResponseWriter, req *http.Request) {
 	var payload addTxPayload
-	utils.HandleError(json.NewDecoder(req.Body).Decode(&payload))
-	tx, err := blockchain.Mempool().AddTx(payload.To, payload.Amount)
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	if !validAddress(rw, payload.To) {
+		return
+	}
+	selector, err := blockchain.NewCoinSelector(payload.Strategy, payload.Inputs)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	tx, err := blockchain.Mempool().AddTx(payload.From, payload.To, payload.Amount, payload.Token, selector)
 	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	p2p.BroadcastNewTx(tx)
+
+	rw.WriteHeader(http.StatusCreated)
+}
+
+// walletSend add new transaction which sends from wallet of name in path to Mempool
+// it return status created with transaction
+func walletSend(rw http.ResponseWriter, req *http.Request) {
+	var payload addTxPayload
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	if !validAddress(rw, payload.To) {
+		return
+	}
+	selector, err := blockchain.NewCoinSelector(payload.Strategy, payload.Inputs)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	tx, err := blockchain.Mempool().AddTx(mux.Vars(req)["name"], payload.To, payload.Amount, payload.Token, selector)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	p2p.BroadcastNewTx(tx)
+
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(tx)
+}
+
+// buildTransaction return unsigned transaction which pays from address to address with its previous outputs
+// if there comes error while creaing transaction, then it return errorMsg with status BadRequest
+func buildTransaction(rw http.ResponseWriter, req *http.Request) {
+	var payload buildTxPayload
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	selector, err := blockchain.NewCoinSelector(payload.Strategy, payload.Inputs)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	unsigned, err := blockchain.BuildTx(payload.From, payload.To, payload.Token, payload.Amount, selector)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	utils.HandleError(json.NewEncoder(rw).Encode(unsigned))
+}
+
+// rawTransaction add transaction signed out of node in Mempool and broadcast it
+// body is transaction as JSON or its canonical encoding(hex)
+// it return ID of transaction with status created
+// if it is rejected, then it return rejection with code and reason with status BadRequest
+func rawTransaction(rw http.ResponseWriter, req *http.Request) {
+	tx, err := decodeRawTx(req)
+	if err == nil {
+		err = blockchain.Mempool().AddRawTx(tx)
+	}
+	if err != nil {
+		rejection, ok := err.(*blockchain.Rejection)
+		if !ok {
+			rejection = &blockchain.Rejection{Code: blockchain.RejectEncoding, Reason: err.Error()}
+		}
 		rw.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(rw).Encode(errorResponse{err.Error()})
+		json.NewEncoder(rw).Encode(rejection)
+		return
+	}
+
+	p2p.BroadcastNewTx(tx)
+
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(rawTxResponse{tx.ID})
+}
+
+// decodeRawTx read transaction from body which is JSON or canonical encoding
+func decodeRawTx(req *http.Request) (*blockchain.Tx, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '{' {
+		tx := &blockchain.Tx{}
+		if err := json.Unmarshal(body, tx); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	}
+	return blockchain.DecodeTx(string(body))
+}
+
+// transactionsData add new transaction which anchors data in Mempool
+// it return status created with transaction
+// if there comes error while creaing transaction, then it return errorMsg with status BadRequest
+func transactionsData(rw http.ResponseWriter, req *http.Request) {
+	var payload addDataPayload
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	tx, err := blockchain.Mempool().AddData(payload.From, payload.Data, payload.Fee)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	p2p.BroadcastNewTx(tx)
+
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(tx)
+}
+
+// anchors return all anchors of data hash in blockChain
+func anchors(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	hash := vars["hash"]
+	utils.HandleError(json.NewEncoder(rw).Encode(blockchain.FindAnchors(blockchain.BlockChain(), hash)))
+}
+
+// htlc lock amount into hash time-locked contract for receiver
+// if hash is not given, then it makes new preimage and returns it with hash
+func htlc(rw http.ResponseWriter, req *http.Request) {
+	var payload addHTLCPayload
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	if !validAddress(rw, payload.To) {
+		return
+	}
+	var preimage string
+	if payload.Hash == "" {
+		preimage = wallet.NewPreimage()
+		payload.Hash = script.Hash(preimage)
+	}
+	tx, err := blockchain.Mempool().AddHTLC(payload.From, payload.To, payload.Amount, payload.Hash, payload.Timeout)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
 		return
 	}
 
 	p2p.BroadcastNewTx(tx)
 
 	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(htlcResponse{
+		TxID:     tx.ID,
+		Index:    len(tx.TxOuts) - 1,
+		Hash:     payload.Hash,
+		Preimage: preimage,
+	})
+}
+
+// claimHTLC spend hash time-locked contract with preimage
+func claimHTLC(rw http.ResponseWriter, req *http.Request) {
+	var payload spendHTLCPayload
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	tx, err := blockchain.Mempool().ClaimHTLC(payload.TxID, payload.Index, payload.Preimage)
+	spendHTLCResponse(rw, tx, err)
+}
+
+// refundHTLC spend hash time-locked contract after timeout
+func refundHTLC(rw http.ResponseWriter, req *http.Request) {
+	var payload spendHTLCPayload
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	tx, err := blockchain.Mempool().RefundHTLC(payload.TxID, payload.Index)
+	spendHTLCResponse(rw, tx, err)
+}
+
+// spendHTLCResponse broadcast transaction spending hash time-locked contract
+// if there comes error while creaing transaction, then it return errorMsg with status BadRequest
+func spendHTLCResponse(rw http.ResponseWriter, tx *blockchain.Tx, err error) {
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	p2p.BroadcastNewTx(tx)
+
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(tx)
 }
 
 // myWallet return address of wallet which is made by public key
 func myWallet(rw http.ResponseWriter, req *http.Request) {
-	address := wallet.Wallet().Address
-	json.NewEncoder(rw).Encode(myWalletResponse{Address: address})
+	w, err := wallet.Get(wallet.DefaultName)
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	json.NewEncoder(rw).Encode(myWalletResponse{Name: w.Name, Address: w.Address, Locked: w.Locked()})
+}
+
+// requestedWallet return loaded wallet of name in path, default wallet if path has no name
+// if it is not loaded, then it write errorMsg with status NotFound and return nil
+func requestedWallet(rw http.ResponseWriter, req *http.Request) *wallet.Info {
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return nil
+	}
+	return w.Info()
+}
+
+// wallets take two methods
+// if request's method is GET, then return all wallets in data directory
+// if request's method is POST, then create new wallet and return it with its mnemonic and status created
+// if mnemonic is given, then wallet is restored from it and its used addresses are rescanned
+func wallets(rw http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "GET":
+		utils.HandleError(json.NewEncoder(rw).Encode(wallet.List()))
+	case "POST":
+		var payload createWalletPayload
+		if !decodePayload(rw, req, &payload) {
+			return
+		}
+		if payload.Mnemonic != "" {
+			used := blockchain.UsedAddresses(blockchain.BlockChain())
+			w, err := wallet.Restore(payload.Name, payload.Passphrase, payload.Mnemonic, func(address string) bool { return used[address] })
+			if err != nil {
+				writeError(rw, http.StatusBadRequest, err)
+				return
+			}
+			rw.WriteHeader(http.StatusCreated)
+			json.NewEncoder(rw).Encode(myWalletResponse{Name: w.Name, Address: w.Address, Locked: w.Locked()})
+			return
+		}
+		w, err := wallet.Create(payload.Name, payload.Passphrase)
+		if err != nil {
+			writeError(rw, http.StatusBadRequest, err)
+			return
+		}
+		mnemonic, err := w.Mnemonic(payload.Passphrase)
+		utils.HandleError(err)
+		rw.WriteHeader(http.StatusCreated)
+		json.NewEncoder(rw).Encode(myWalletResponse{Name: w.Name, Address: w.Address, Locked: w.Locked(), Mnemonic: mnemonic})
+	}
+}
+
+// loadWallet load wallet of name in path from its wallet file, unlocked if passphrase is given
+func loadWallet(rw http.ResponseWriter, req *http.Request) {
+	var payload createWalletPayload
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	w, err := wallet.Load(mux.Vars(req)["name"], payload.Passphrase)
+	if err == wallet.ErrWalletNotFound {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	json.NewEncoder(rw).Encode(myWalletResponse{Name: w.Name, Address: w.Address, Locked: w.Locked()})
+}
+
+// walletBalance return balance of wallet of name in path
+// token is given by query like balance
+func walletBalance(rw http.ResponseWriter, req *http.Request) {
+	info := requestedWallet(rw, req)
+	if info == nil {
+		return
+	}
+	token := req.URL.Query().Get("token")
+	amount := 0
+	for _, address := range info.Addresses {
+		amount += blockchain.TokenBalanceByAddress(address, token, blockchain.BlockChain())
+	}
+	utils.HandleError(json.NewEncoder(rw).Encode(balanceResponse{Address: info.Address, Token: token, Balance: amount}))
+}
+
+// unlockWallet decrypt key of wallet of name in path with passphrase, default wallet if path has no name
+// if passphrase is wrong, then it return errorMsg with status Unauthorized
+func unlockWallet(rw http.ResponseWriter, req *http.Request) {
+	var payload unlockWalletPayload
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	if err := w.Unlock(payload.Passphrase, time.Duration(payload.Timeout)*time.Second); err != nil {
+		writeError(rw, http.StatusUnauthorized, err)
+		return
+	}
+	json.NewEncoder(rw).Encode(myWalletResponse{Name: w.Name, Address: w.Address, Locked: w.Locked()})
+}
+
+// lockWallet forget decrypted key of wallet of name in path, default wallet if path has no name
+func lockWallet(rw http.ResponseWriter, req *http.Request) {
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	w.Lock()
+	json.NewEncoder(rw).Encode(myWalletResponse{Name: w.Name, Address: w.Address, Locked: w.Locked()})
+}
+
+// newAddress derive fresh receive address of HD wallet of name in path, default wallet if path has no name
+// if wallet is not HD wallet, then it return errorMsg with status BadRequest
+func newAddress(rw http.ResponseWriter, req *http.Request) {
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	address, err := w.NewAddress()
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	json.NewEncoder(rw).Encode(newAddressResponse{address})
+}
+
+// rescanWallet restore used addresses of HD wallet of name in path and return all its addresses
+func rescanWallet(rw http.ResponseWriter, req *http.Request) {
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	used := blockchain.UsedAddresses(blockchain.BlockChain())
+	addresses, err := w.Rescan(func(address string) bool { return used[address] })
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	utils.HandleError(json.NewEncoder(rw).Encode(addresses))
+}
+
+// walletMnemonic return mnemonic of HD wallet of name in path for backup
+// if passphrase is wrong, then it return errorMsg with status Unauthorized
+func walletMnemonic(rw http.ResponseWriter, req *http.Request) {
+	var payload unlockWalletPayload
+	if !decodePayload(rw, req, &payload) {
+		return
+	}
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	mnemonic, err := w.Mnemonic(payload.Passphrase)
+	if err == wallet.ErrNotHD {
+		writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(rw, http.StatusUnauthorized, err)
+		return
+	}
+	json.NewEncoder(rw).Encode(mnemonicResponse{mnemonic})
+}
+
+// walletTransactions return transactions which pay to or spend from addresses of wallet of name in path
+// each of them carries its label if wallet has one
+func walletTransactions(rw http.ResponseWriter, req *http.Request) {
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	labels := w.Labels()
+	txs := blockchain.WalletTxs(w.Addresses(), blockchain.BlockChain())
+	for _, tx := range txs {
+		tx.Label = labels.Txs[tx.TxID]
+	}
+	utils.HandleError(json.NewEncoder(rw).Encode(txs))
+}
+
+// walletLabels take two methods
+// if request's method is GET, then return labels of wallet of name in path
+// if request's method is POST, then set label of transaction or address and return all labels
+func walletLabels(rw http.ResponseWriter, req *http.Request) {
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	if req.Method == "POST" {
+		var payload labelPayload
+		if !decodePayload(rw, req, &payload) {
+			return
+		}
+		switch {
+		case payload.TxID != "" && payload.Address == "":
+			err = w.SetTxLabel(payload.TxID, payload.Label)
+		case payload.Address != "" && payload.TxID == "":
+			err = w.SetAddressLabel(payload.Address, payload.Label)
+		default:
+			err = wallet.ErrNoLabelTarget
+		}
+		if err != nil {
+			writeError(rw, http.StatusBadRequest, err)
+			return
+		}
+	}
+	utils.HandleError(json.NewEncoder(rw).Encode(w.Labels()))
+}
+
+// walletWatch take two methods
+// if request's method is GET, then return watch-only addresses of wallet of name in path with their balances
+// token is given by query like balance
+// if request's method is POST, then register addresses as watch-only and return them in same way
+func walletWatch(rw http.ResponseWriter, req *http.Request) {
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	if req.Method == "POST" {
+		var payload watchPayload
+		if !decodePayload(rw, req, &payload) {
+			return
+		}
+		if err := w.Watch(payload.Addresses...); err != nil {
+			writeError(rw, http.StatusBadRequest, err)
+			return
+		}
+	}
+	token := req.URL.Query().Get("token")
+	response := watchResponse{Addresses: []balanceResponse{}, Token: token}
+	for _, address := range w.Watched() {
+		amount := blockchain.TokenBalanceByAddress(address, token, blockchain.BlockChain())
+		response.Addresses = append(response.Addresses, balanceResponse{Address: address, Token: token, Balance: amount})
+		response.Balance += amount
+	}
+	utils.HandleError(json.NewEncoder(rw).Encode(response))
+}
+
+// watchTransactions return transactions which pay to or spend from watch-only addresses of wallet of name in path
+func watchTransactions(rw http.ResponseWriter, req *http.Request) {
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	labels := w.Labels()
+	txs := blockchain.WalletTxs(w.Watched(), blockchain.BlockChain())
+	for _, tx := range txs {
+		tx.Label = labels.Txs[tx.TxID]
+	}
+	utils.HandleError(json.NewEncoder(rw).Encode(txs))
+}
+
+// watchEvents return recent notifications of watch-only addresses of wallet of name in path, newest first
+func watchEvents(rw http.ResponseWriter, req *http.Request) {
+	w, err := wallet.Get(mux.Vars(req)["name"])
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+		return
+	}
+	utils.HandleError(json.NewEncoder(rw).Encode(blockchain.WatchEvents(w.Watched())))
 }
 
 func peers(rw http.ResponseWriter, req *http.Request) {
@@ -184,13 +1038,55 @@ func peers(rw http.ResponseWriter, req *http.Request) {
 	case "POST":
 		var payload addPeerPayLoad
 		json.NewDecoder(req.Body).Decode(&payload)
-		p2p.AddPeer(payload.Address, payload.Port, port[1:], true)
+		if err := p2p.AddPeer(payload.Address, payload.Port, port[1:], true); err != nil {
+			writeError(rw, http.StatusBadRequest, err)
+			return
+		}
 		rw.WriteHeader(http.StatusOK)
 	case "GET":
 		json.NewEncoder(rw).Encode(p2p.AllPeers(&p2p.Peers))
 	}
 }
 
+// backup stream consistent copy of DB
+// error is written only if nothing of copy is written yet, otherwise connection is aborted so client doesn't keep partial copy
+func backup(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/octet-stream")
+	rw.Header().Set("Content-Disposition", `attachment; filename="blockchain.db"`)
+	n, err := blockchain.Backup(rw)
+	if err == nil {
+		return
+	}
+	if n > 0 {
+		log.Printf("Backup failed after %d bytes: %s\n", n, err)
+		panic(http.ErrAbortHandler)
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	writeError(rw, http.StatusInternalServerError, err)
+}
+
+// errNotLocal is error returned when admin endpoint is requested from other machine
+var errNotLocal = errors.New("Admin endpoint is only served to localhost")
+
+// localOnly serve next only to requests from loopback address, others get errorResponse with status Forbidden
+// node behind reverse proxy on same machine must restrict admin endpoints in proxy
+func localOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			writeError(rw, http.StatusForbidden, errNotLocal)
+			return
+		}
+		next(rw, req)
+	}
+}
+
+// writeError write message of err as errorResponse with status
+func writeError(rw http.ResponseWriter, status int, err error) {
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(errorResponse{err.Error()})
+}
+
 // jsonContentTypeMiddleWare define content type of all response to  {@code application/json}
 // this function use 'Adapter Pattern'
 func jsonContentTypeMiddleWare(next http.Handler) http.Handler {
@@ -203,7 +1099,7 @@ func jsonContentTypeMiddleWare(next http.Handler) http.Handler {
 // loggerMiddleWare log request URL
 func loggerMiddleWare(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-		fmt.Println(req.URL)
+		log.Println(req.URL)
 		next.ServeHTTP(rw, req)
 	})
 }
@@ -218,11 +1114,45 @@ func Start(portNum int) {
 	router.HandleFunc("/blocks", blocks).Methods("GET", "POST")
 	router.HandleFunc("/blocks/{hash:[a-f0-9]+}", block).Methods("GET")
 	router.HandleFunc("/balance/{address}", balance).Methods("GET")
+	router.HandleFunc("/tokens", tokens).Methods("GET", "POST")
 	router.HandleFunc("/mempool", mempool).Methods("GET")
 	router.HandleFunc("/wallet", myWallet).Methods("GET")
+	router.HandleFunc("/wallet/unlock", unlockWallet).Methods("POST")
+	router.HandleFunc("/wallet/lock", lockWallet).Methods("POST")
+	router.HandleFunc("/wallet/newaddress", newAddress).Methods("GET")
+	router.HandleFunc("/wallet/rescan", rescanWallet).Methods("POST")
+	router.HandleFunc("/wallet/mnemonic", walletMnemonic).Methods("POST")
+	router.HandleFunc("/wallet/transactions", walletTransactions).Methods("GET")
+	router.HandleFunc("/wallet/labels", walletLabels).Methods("GET", "POST")
+	router.HandleFunc("/wallet/watch", walletWatch).Methods("GET", "POST")
+	router.HandleFunc("/wallet/watch/transactions", watchTransactions).Methods("GET")
+	router.HandleFunc("/wallet/watch/events", watchEvents).Methods("GET")
+	router.HandleFunc("/wallets", wallets).Methods("GET", "POST")
+	router.HandleFunc("/wallets/{name}/load", loadWallet).Methods("POST")
+	router.HandleFunc("/wallets/{name}/unlock", unlockWallet).Methods("POST")
+	router.HandleFunc("/wallets/{name}/lock", lockWallet).Methods("POST")
+	router.HandleFunc("/wallets/{name}/newaddress", newAddress).Methods("GET")
+	router.HandleFunc("/wallets/{name}/rescan", rescanWallet).Methods("POST")
+	router.HandleFunc("/wallets/{name}/mnemonic", walletMnemonic).Methods("POST")
+	router.HandleFunc("/wallets/{name}/balance", walletBalance).Methods("GET")
+	router.HandleFunc("/wallets/{name}/send", walletSend).Methods("POST")
+	router.HandleFunc("/wallets/{name}/transactions", walletTransactions).Methods("GET")
+	router.HandleFunc("/wallets/{name}/labels", walletLabels).Methods("GET", "POST")
+	router.HandleFunc("/wallets/{name}/watch", walletWatch).Methods("GET", "POST")
+	router.HandleFunc("/wallets/{name}/watch/transactions", watchTransactions).Methods("GET")
+	router.HandleFunc("/wallets/{name}/watch/events", watchEvents).Methods("GET")
 	router.HandleFunc("/transactions", transactions).Methods("POST")
+	router.HandleFunc("/transactions/data", transactionsData).Methods("POST")
+	router.HandleFunc("/transactions/build", buildTransaction).Methods("POST")
+	router.HandleFunc("/transactions/raw", rawTransaction).Methods("POST")
+	router.HandleFunc("/anchors/{hash:[a-fA-F0-9]+}", anchors).Methods("GET")
+	router.HandleFunc("/htlc", htlc).Methods("POST")
+	router.HandleFunc("/htlc/claim", claimHTLC).Methods("POST")
+	router.HandleFunc("/htlc/refund", refundHTLC).Methods("POST")
+	router.HandleFunc("/admin/backup", localOnly(backup)).Methods("GET")
 	router.HandleFunc("/ws", p2p.Upgrade).Methods("GET")
 	router.HandleFunc("/peers", peers).Methods("GET", "POST")
+	go p2p.ConnectKnownPeers(port[1:])
 	fmt.Printf("REST Listening on http://localhost%s\n", port)
 	log.Fatal(http.ListenAndServe(port, router))
 }
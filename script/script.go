@@ -0,0 +1,483 @@
+package script
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+// Opcodes of script language
+// every token of script which is not an opcode is pushed onto the stack as data
+const (
+	OpDup                 = "OP_DUP"
+	OpDrop                = "OP_DROP"
+	OpSwap                = "OP_SWAP"
+	OpEqual               = "OP_EQUAL"
+	OpEqualVerify         = "OP_EQUALVERIFY"
+	OpVerify              = "OP_VERIFY"
+	OpReturn              = "OP_RETURN"
+	OpIf                  = "OP_IF"
+	OpElse                = "OP_ELSE"
+	OpEndIf               = "OP_ENDIF"
+	OpAdd                 = "OP_ADD"
+	OpSub                 = "OP_SUB"
+	OpNumEqual            = "OP_NUMEQUAL"
+	OpLessThan            = "OP_LESSTHAN"
+	OpGreaterThan         = "OP_GREATERTHAN"
+	OpSha256              = "OP_SHA256"
+	OpCheckSig            = "OP_CHECKSIG"
+	OpCheckSigVerify      = "OP_CHECKSIGVERIFY"
+	OpCheckMultiSig       = "OP_CHECKMULTISIG"
+	OpCheckLockTimeVerify = "OP_CHECKLOCKTIMEVERIFY"
+)
+
+const (
+	opPrefix  = "OP_"
+	trueItem  = "1"
+	falseItem = "0"
+
+	// lockTimeThreshold divide locktime into block height and unix timestamp
+	// locktime lower than this is compared with height, others with timestamp
+	lockTimeThreshold int = 500000000
+)
+
+var (
+	//ErrStackUnderflow is error returned when opcode need more items than stack has
+	ErrStackUnderflow = errors.New("Stack underflow")
+	//ErrUnknownOpcode is error returned when script contain undefined opcode
+	ErrUnknownOpcode = errors.New("Unknown opcode")
+	//ErrNotNumber is error returned when arithmetic opcode meet non-number item
+	ErrNotNumber = errors.New("Item is not a number")
+	//ErrVerifyFailed is error returned when *VERIFY opcode fails
+	ErrVerifyFailed = errors.New("Verify failed")
+	//ErrUnbalancedIf is error returned when OP_IF, OP_ELSE and OP_ENDIF don't match
+	ErrUnbalancedIf = errors.New("Unbalanced conditional")
+	//ErrReturn is error returned when script meet OP_RETURN
+	ErrReturn = errors.New("Script is unspendable")
+	//ErrLockTime is error returned when locktime is not reached yet
+	ErrLockTime = errors.New("Locktime is not reached")
+	//ErrScriptFalse is error returned when script ends with false or empty stack
+	ErrScriptFalse = errors.New("Script evaluated to false")
+)
+
+// Context is environment where script is evaluated
+// Payload is message which signature signs (transaction id)
+// Height and Timestamp is used for time-lock
+type Context struct {
+	Payload   string
+	Height    int
+	Timestamp int
+}
+
+type stack []string
+
+func (s *stack) push(item string) {
+	*s = append(*s, item)
+}
+
+func (s *stack) pop() (string, error) {
+	if len(*s) == 0 {
+		return "", ErrStackUnderflow
+	}
+	item := (*s)[len(*s)-1]
+	*s = (*s)[:len(*s)-1]
+	return item, nil
+}
+
+func (s *stack) peek() (string, error) {
+	if len(*s) == 0 {
+		return "", ErrStackUnderflow
+	}
+	return (*s)[len(*s)-1], nil
+}
+
+func (s *stack) popNumber() (int, error) {
+	item, err := s.pop()
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(item)
+	if err != nil {
+		return 0, ErrNotNumber
+	}
+	return n, nil
+}
+
+// isTrue return whether item means true
+// empty item and zero are false, all others are true
+func isTrue(item string) bool {
+	return item != "" && item != falseItem
+}
+
+func fromBool(b bool) string {
+	if b {
+		return trueItem
+	}
+	return falseItem
+}
+
+// executing return whether all conditional branches we are in are taken
+func executing(branches []bool) bool {
+	for _, branch := range branches {
+		if !branch {
+			return false
+		}
+	}
+	return true
+}
+
+//Run evaluate unlocking script followed by locking script
+//it returns nil if locking script is satisfied by unlocking script
+func Run(unlocking, locking string, ctx *Context) error {
+	s := stack{}
+	if err := execute(unlocking, &s, ctx); err != nil {
+		return err
+	}
+	if err := execute(locking, &s, ctx); err != nil {
+		return err
+	}
+	top, err := s.peek()
+	if err != nil || !isTrue(top) {
+		return ErrScriptFalse
+	}
+	return nil
+}
+
+// execute run every token of script on stack
+func execute(script string, s *stack, ctx *Context) error {
+	var branches []bool
+	for _, token := range strings.Fields(script) {
+		switch token {
+		case OpIf:
+			taken := false
+			if executing(branches) {
+				item, err := s.pop()
+				if err != nil {
+					return err
+				}
+				taken = isTrue(item)
+			}
+			branches = append(branches, taken)
+			continue
+		case OpElse:
+			if len(branches) == 0 {
+				return ErrUnbalancedIf
+			}
+			branches[len(branches)-1] = !branches[len(branches)-1]
+			continue
+		case OpEndIf:
+			if len(branches) == 0 {
+				return ErrUnbalancedIf
+			}
+			branches = branches[:len(branches)-1]
+			continue
+		}
+
+		if !executing(branches) {
+			continue
+		}
+		if err := step(token, s, ctx); err != nil {
+			return err
+		}
+	}
+	if len(branches) != 0 {
+		return ErrUnbalancedIf
+	}
+	return nil
+}
+
+// step run a token which is not conditional opcode
+func step(token string, s *stack, ctx *Context) error {
+	if !strings.HasPrefix(token, opPrefix) {
+		s.push(token)
+		return nil
+	}
+
+	switch token {
+	case OpDup:
+		item, err := s.peek()
+		if err != nil {
+			return err
+		}
+		s.push(item)
+	case OpDrop:
+		_, err := s.pop()
+		return err
+	case OpSwap:
+		a, err := s.pop()
+		if err != nil {
+			return err
+		}
+		b, err := s.pop()
+		if err != nil {
+			return err
+		}
+		s.push(a)
+		s.push(b)
+	case OpEqual, OpEqualVerify:
+		a, err := s.pop()
+		if err != nil {
+			return err
+		}
+		b, err := s.pop()
+		if err != nil {
+			return err
+		}
+		if token == OpEqualVerify {
+			return verify(a == b)
+		}
+		s.push(fromBool(a == b))
+	case OpVerify:
+		item, err := s.pop()
+		if err != nil {
+			return err
+		}
+		return verify(isTrue(item))
+	case OpReturn:
+		return ErrReturn
+	case OpAdd, OpSub, OpNumEqual, OpLessThan, OpGreaterThan:
+		b, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		a, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		s.push(arithmetic(token, a, b))
+	case OpSha256:
+		item, err := s.pop()
+		if err != nil {
+			return err
+		}
+		s.push(Hash(item))
+	case OpCheckSig, OpCheckSigVerify:
+		publicKey, err := s.pop()
+		if err != nil {
+			return err
+		}
+		signature, err := s.pop()
+		if err != nil {
+			return err
+		}
+		ok := wallet.Verify(signature, ctx.Payload, publicKey)
+		if token == OpCheckSigVerify {
+			return verify(ok)
+		}
+		s.push(fromBool(ok))
+	case OpCheckMultiSig:
+		ok, err := checkMultiSig(s, ctx)
+		if err != nil {
+			return err
+		}
+		s.push(fromBool(ok))
+	case OpCheckLockTimeVerify:
+		item, err := s.peek()
+		if err != nil {
+			return err
+		}
+		lockTime, err := strconv.Atoi(item)
+		if err != nil {
+			return ErrNotNumber
+		}
+		if !lockTimeReached(lockTime, ctx) {
+			return ErrLockTime
+		}
+	default:
+		return ErrUnknownOpcode
+	}
+	return nil
+}
+
+func verify(ok bool) error {
+	if !ok {
+		return ErrVerifyFailed
+	}
+	return nil
+}
+
+func arithmetic(op string, a, b int) string {
+	switch op {
+	case OpAdd:
+		return strconv.Itoa(a + b)
+	case OpSub:
+		return strconv.Itoa(a - b)
+	case OpNumEqual:
+		return fromBool(a == b)
+	case OpLessThan:
+		return fromBool(a < b)
+	default:
+		return fromBool(a > b)
+	}
+}
+
+// lockTimeReached compare locktime with height or timestamp of context
+func lockTimeReached(lockTime int, ctx *Context) bool {
+	if lockTime < lockTimeThreshold {
+		return ctx.Height >= lockTime
+	}
+	return ctx.Timestamp >= lockTime
+}
+
+// checkMultiSig pop <n> <public key>... <m> <signature>... from stack
+// then check every signature matches with one of public keys in order
+func checkMultiSig(s *stack, ctx *Context) (bool, error) {
+	n, err := s.popNumber()
+	if err != nil {
+		return false, err
+	}
+	if n < 0 || n > len(*s) {
+		return false, ErrStackUnderflow
+	}
+	publicKeys := make([]string, n)
+	for i := n - 1; i >= 0; i-- {
+		if publicKeys[i], err = s.pop(); err != nil {
+			return false, err
+		}
+	}
+	m, err := s.popNumber()
+	if err != nil {
+		return false, err
+	}
+	if m < 0 || m > len(*s) {
+		return false, ErrStackUnderflow
+	}
+	signatures := make([]string, m)
+	for i := m - 1; i >= 0; i-- {
+		if signatures[i], err = s.pop(); err != nil {
+			return false, err
+		}
+	}
+
+	cursor := 0
+	for _, signature := range signatures {
+		for cursor < len(publicKeys) && !wallet.Verify(signature, ctx.Payload, publicKeys[cursor]) {
+			cursor++
+		}
+		if cursor == len(publicKeys) {
+			return false, nil
+		}
+		cursor++
+	}
+	return true, nil
+}
+
+//Hash return hexa-decimal sha256 hash of item, same as OP_SHA256
+func Hash(item string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(item)))
+}
+
+//IsHash return whether item has form of hash made by Hash, 64 lowercase hexa-decimal characters
+func IsHash(item string) bool {
+	if len(item) != 2*sha256.Size {
+		return false
+	}
+	for _, c := range item {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// ------------------- standard script templates --------------
+
+//PayToAddress return locking script for output which is spendable by owner of address carrying public key
+//it is unlocked by Unlock(signature), outputs are paid to PayToPubKeyHash now
+func PayToAddress(address string) string {
+	return strings.Join([]string{address, OpCheckSig}, " ")
+}
+
+//PayToPubKeyHash return locking script for output which is spendable by owner of public key of hash
+//it is unlocked by Unlock(signature, publicKey)
+func PayToPubKeyHash(hash string) string {
+	return strings.Join([]string{OpDup, OpSha256, hash, OpEqualVerify, OpCheckSig}, " ")
+}
+
+//RevealsPublicKey return whether locking script checks hash of public key
+//then public key must follow signature in unlocking script
+func RevealsPublicKey(locking string) bool {
+	return strings.Contains(locking, OpDup+" "+OpSha256)
+}
+
+//MultiSig return locking script for output which needs m signatures of public keys
+func MultiSig(m int, publicKeys ...string) string {
+	tokens := []string{strconv.Itoa(m)}
+	tokens = append(tokens, publicKeys...)
+	tokens = append(tokens, strconv.Itoa(len(publicKeys)), OpCheckMultiSig)
+	return strings.Join(tokens, " ")
+}
+
+//Unlock return unlocking script made by pushing all items in order
+func Unlock(items ...string) string {
+	return strings.Join(items, " ")
+}
+
+//HashTimeLock return locking script of hash time-locked contract between hashes of public keys
+//receiver can spend it with preimage of hash, sender can spend it after timeout(block height or timestamp)
+func HashTimeLock(receiver, sender, hash string, timeout int) string {
+	return strings.Join([]string{
+		OpIf, OpSha256, hash, OpEqualVerify, OpDup, OpSha256, receiver,
+		OpElse, strconv.Itoa(timeout), OpCheckLockTimeVerify, OpDrop, OpDup, OpSha256, sender,
+		OpEndIf, OpEqualVerify, OpCheckSig,
+	}, " ")
+}
+
+//ClaimHashTimeLock return unlocking script for receiver of hash time-locked contract
+//credential is Unlock(signature, publicKey), or signature for contract made before hash of public key
+func ClaimHashTimeLock(credential, preimage string) string {
+	return Unlock(credential, preimage, trueItem)
+}
+
+//RefundHashTimeLock return unlocking script for sender of hash time-locked contract
+//credential is same as ClaimHashTimeLock
+func RefundHashTimeLock(credential string) string {
+	return Unlock(credential, falseItem)
+}
+
+//IsHashTimeLock return whether locking script is made by HashTimeLock
+//contract made before hash of public key, whose branches end with public keys, is recognized too
+func IsHashTimeLock(locking string) bool {
+	tokens := strings.Fields(locking)
+	if len(tokens) == 12 {
+		return tokens[0] == OpIf && tokens[5] == OpElse && tokens[11] == OpCheckSig
+	}
+	return len(tokens) == 17 && tokens[0] == OpIf && tokens[7] == OpElse && tokens[16] == OpCheckSig
+}
+
+//HashTimeLockParties return items of receiver and sender in locking script made by HashTimeLock
+//they are hashes of public keys, or public keys in contract made before hash of public key
+func HashTimeLockParties(locking string) (receiver, sender string, ok bool) {
+	if !IsHashTimeLock(locking) {
+		return "", "", false
+	}
+	tokens := strings.Fields(locking)
+	if len(tokens) == 12 {
+		return tokens[4], tokens[9], true
+	}
+	return tokens[6], tokens[13], true
+}
+
+//Data return provably unspendable locking script which carries data
+func Data(data string) string {
+	return strings.Join([]string{OpReturn, data}, " ")
+}
+
+//IsData return whether locking script is made by Data
+func IsData(locking string) bool {
+	tokens := strings.Fields(locking)
+	return len(tokens) > 0 && tokens[0] == OpReturn
+}
+
+//DataOf return data carried by locking script made by Data
+func DataOf(locking string) string {
+	tokens := strings.Fields(locking)
+	if len(tokens) != 2 || tokens[0] != OpReturn {
+		return ""
+	}
+	return tokens[1]
+}
@@ -0,0 +1,227 @@
+package script
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Gunyoung-Kim/blockchain/config"
+	"github.com/Gunyoung-Kim/blockchain/utils"
+	"github.com/Gunyoung-Kim/blockchain/wallet"
+)
+
+const payload = "5f1c0e3a" // transaction id which signatures sign
+
+// key is public key of wallet address and its hash
+type key struct {
+	address   string
+	publicKey string
+	hash      string
+}
+
+var keys []*key
+
+// TestMain derive keys from wallet in memory, so scripts can be signed without touching disk
+func TestMain(m *testing.M) {
+	config.UseMemory()
+	utils.HandleError(wallet.Open(""))
+	addresses := []string{wallet.Wallet().Address}
+	for i := 0; i < 2; i++ {
+		address, err := wallet.Wallet().NewAddress()
+		utils.HandleError(err)
+		addresses = append(addresses, address)
+	}
+	for _, address := range addresses {
+		publicKey, err := wallet.Wallet().PublicKeyAs(address)
+		utils.HandleError(err)
+		hash, err := wallet.PublicKeyHashOf(address)
+		utils.HandleError(err)
+		keys = append(keys, &key{address, publicKey, hash})
+	}
+	os.Exit(m.Run())
+}
+
+// sign return signature of payload with key
+func sign(t *testing.T, k *key, payload string) string {
+	t.Helper()
+	signature, err := wallet.Wallet().SignAs(payload, k.address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+type scriptTest struct {
+	name      string
+	unlocking string
+	locking   string
+	ctx       *Context
+	want      error
+}
+
+func run(t *testing.T, tests []scriptTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := test.ctx
+			if ctx == nil {
+				ctx = &Context{Payload: payload}
+			}
+			if err := Run(test.unlocking, test.locking, ctx); !errors.Is(err, test.want) {
+				t.Errorf("Run(%q, %q) = %v, want %v", test.unlocking, test.locking, err, test.want)
+			}
+		})
+	}
+}
+
+func TestOpcodes(t *testing.T) {
+	run(t, []scriptTest{
+		{"push", "", "1", nil, nil},
+		{"push false", "", "0", nil, ErrScriptFalse},
+		{"empty", "", "", nil, ErrScriptFalse},
+		{"dup", "a", "OP_DUP OP_EQUAL", nil, nil},
+		{"drop", "1 0", "OP_DROP", nil, nil},
+		{"swap", "a b", "OP_SWAP a OP_EQUAL", nil, nil},
+		{"equal", "a a", "OP_EQUAL", nil, nil},
+		{"not equal", "a b", "OP_EQUAL", nil, ErrScriptFalse},
+		{"equalverify", "a a", "OP_EQUALVERIFY 1", nil, nil},
+		{"equalverify fails", "a b", "OP_EQUALVERIFY 1", nil, ErrVerifyFailed},
+		{"verify", "1", "OP_VERIFY 1", nil, nil},
+		{"verify fails", "0", "OP_VERIFY 1", nil, ErrVerifyFailed},
+		{"return", "1", "OP_RETURN", nil, ErrReturn},
+		{"add", "2 3", "OP_ADD 5 OP_NUMEQUAL", nil, nil},
+		{"sub", "5 3", "OP_SUB 2 OP_NUMEQUAL", nil, nil},
+		{"numequal", "2 3", "OP_NUMEQUAL", nil, ErrScriptFalse},
+		{"lessthan", "2 3", "OP_LESSTHAN", nil, nil},
+		{"greaterthan", "2 3", "OP_GREATERTHAN", nil, ErrScriptFalse},
+		{"not number", "a 3", "OP_ADD", nil, ErrNotNumber},
+		{"sha256", "abc", "OP_SHA256 ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad OP_EQUAL", nil, nil},
+		{"if taken", "1", "OP_IF a OP_ELSE b OP_ENDIF a OP_EQUAL", nil, nil},
+		{"else taken", "0", "OP_IF a OP_ELSE b OP_ENDIF b OP_EQUAL", nil, nil},
+		{"nested if skipped", "0", "OP_IF OP_IF OP_RETURN OP_ENDIF OP_ENDIF 1", nil, nil},
+		{"if without endif", "1", "OP_IF 1", nil, ErrUnbalancedIf},
+		{"endif without if", "1", "OP_ENDIF", nil, ErrUnbalancedIf},
+		{"else without if", "1", "OP_ELSE", nil, ErrUnbalancedIf},
+		{"unknown opcode", "1", "OP_NOP", nil, ErrUnknownOpcode},
+	})
+}
+
+func TestStackUnderflow(t *testing.T) {
+	var tests []scriptTest
+	for _, op := range []string{
+		OpDup, OpDrop, OpEqual, OpEqualVerify, OpVerify, OpIf, OpSha256,
+		OpCheckSig, OpCheckSigVerify, OpCheckMultiSig, OpCheckLockTimeVerify,
+	} {
+		tests = append(tests, scriptTest{op, "", op, nil, ErrStackUnderflow})
+	}
+	for _, op := range []string{OpSwap, OpAdd, OpSub, OpNumEqual, OpLessThan, OpGreaterThan} {
+		tests = append(tests, scriptTest{op + " with one item", "1", op, nil, ErrStackUnderflow})
+	}
+	tests = append(tests,
+		scriptTest{"multisig needs more public keys", "3", OpCheckMultiSig, nil, ErrStackUnderflow},
+		scriptTest{"multisig needs more signatures", "2 a 1", OpCheckMultiSig, nil, ErrStackUnderflow},
+	)
+	run(t, tests)
+}
+
+func TestPayToPubKeyHash(t *testing.T) {
+	owner, other := keys[0], keys[1]
+	locking := PayToPubKeyHash(owner.hash)
+	run(t, []scriptTest{
+		{"owner", Unlock(sign(t, owner, payload), owner.publicKey), locking, nil, nil},
+		{"public key of other", Unlock(sign(t, other, payload), other.publicKey), locking, nil, ErrVerifyFailed},
+		{"signature of other payload", Unlock(sign(t, owner, "0badc0de"), owner.publicKey), locking, nil, ErrScriptFalse},
+		{"signature of other key", Unlock(sign(t, other, payload), owner.publicKey), locking, nil, ErrScriptFalse},
+		{"no public key", sign(t, owner, payload), locking, nil, ErrVerifyFailed},
+		{"nothing", "", locking, nil, ErrStackUnderflow},
+	})
+	if !RevealsPublicKey(locking) {
+		t.Error("pay-to-pubkey-hash doesn't reveal public key")
+	}
+}
+
+func TestCheckLockTimeVerify(t *testing.T) {
+	locking := func(lockTime string) string {
+		return lockTime + " OP_CHECKLOCKTIMEVERIFY OP_DROP 1"
+	}
+	at := func(height, timestamp int) *Context {
+		return &Context{Payload: payload, Height: height, Timestamp: timestamp}
+	}
+	run(t, []scriptTest{
+		{"height reached", "", locking("10"), at(10, 0), nil},
+		{"height not reached", "", locking("10"), at(9, 2000000000), ErrLockTime},
+		{"zero height", "", locking("0"), at(0, 0), nil},
+		{"last height below threshold", "", locking("499999999"), at(0, 2000000000), ErrLockTime},
+		{"timestamp at threshold", "", locking("500000000"), at(2000000000, 500000000), nil},
+		{"timestamp not reached", "", locking("500000001"), at(2000000000, 500000000), ErrLockTime},
+		{"not number", "", locking("soon"), at(10, 0), ErrNotNumber},
+		{"lock time stays on stack", "", "10 OP_CHECKLOCKTIMEVERIFY", at(10, 0), nil},
+	})
+}
+
+func TestHashTimeLock(t *testing.T) {
+	receiver, sender := keys[0], keys[1]
+	preimage := "secret"
+	locking := HashTimeLock(receiver.hash, sender.hash, Hash(preimage), 100)
+	credential := func(k *key) string {
+		return Unlock(sign(t, k, payload), k.publicKey)
+	}
+	before := &Context{Payload: payload, Height: 99}
+	after := &Context{Payload: payload, Height: 100}
+	run(t, []scriptTest{
+		{"claim", ClaimHashTimeLock(credential(receiver), preimage), locking, before, nil},
+		{"claim with wrong preimage", ClaimHashTimeLock(credential(receiver), "guess"), locking, before, ErrVerifyFailed},
+		{"claim by sender", ClaimHashTimeLock(credential(sender), preimage), locking, before, ErrVerifyFailed},
+		{"refund before timeout", RefundHashTimeLock(credential(sender)), locking, before, ErrLockTime},
+		{"refund after timeout", RefundHashTimeLock(credential(sender)), locking, after, nil},
+		{"refund by receiver", RefundHashTimeLock(credential(receiver)), locking, after, ErrVerifyFailed},
+	})
+
+	if !IsHashTimeLock(locking) {
+		t.Fatal("IsHashTimeLock doesn't recognize contract")
+	}
+	if gotReceiver, gotSender, ok := HashTimeLockParties(locking); !ok || gotReceiver != receiver.hash || gotSender != sender.hash {
+		t.Errorf("HashTimeLockParties = %s, %s, %t, want %s, %s", gotReceiver, gotSender, ok, receiver.hash, sender.hash)
+	}
+	if _, _, ok := HashTimeLockParties(PayToPubKeyHash(receiver.hash)); ok {
+		t.Error("HashTimeLockParties accepts pay-to-pubkey-hash")
+	}
+}
+
+func TestMultiSig(t *testing.T) {
+	locking := MultiSig(2, keys[0].publicKey, keys[1].publicKey, keys[2].publicKey)
+	signature := func(i int) string {
+		return sign(t, keys[i], payload)
+	}
+	run(t, []scriptTest{
+		{"first and second", Unlock("2", signature(0), signature(1)), locking, nil, nil},
+		{"first and third", Unlock("2", signature(0), signature(2)), locking, nil, nil},
+		{"out of order", Unlock("2", signature(2), signature(0)), locking, nil, ErrScriptFalse},
+		{"same signature twice", Unlock("2", signature(1), signature(1)), locking, nil, ErrScriptFalse},
+		{"one signature", Unlock("1", signature(0)), locking, nil, ErrScriptFalse},
+	})
+}
+
+func TestData(t *testing.T) {
+	locking := Data("cafe")
+	if !IsData(locking) || DataOf(locking) != "cafe" {
+		t.Errorf("data script %q carries %q", locking, DataOf(locking))
+	}
+	run(t, []scriptTest{{"spend data", "1", locking, nil, ErrReturn}})
+}
+
+func TestIsHash(t *testing.T) {
+	tests := map[string]bool{
+		Hash("abc"):                  true,
+		strings.ToUpper(Hash("abc")): false,
+		Hash("abc")[:62]:             false,
+		Hash("abc")[:62] + " 1":      false,
+		Hash("abc")[:63] + "g":       false,
+	}
+	for item, want := range tests {
+		if got := IsHash(item); got != want {
+			t.Errorf("IsHash(%q) = %t, want %t", item, got, want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Gunyoung-Kim/blockchain/config"
+)
+
+// Address is base58check encoding of version byte of network, hash of public key and checksum
+// public key is hexa-decimal compressed point and its hash is sha256 of it like OP_SHA256
+// checksum is first bytes of double sha256 of version and hash, so typo in address is detected
+// older addresses carry public key itself, hexa-decimal X and Y (legacy) or compressed point after version,
+// they are still accepted and normalized into address of hash of their public key
+const (
+	checksumLen      int = 4
+	hashLen          int = 32  // length of hash of public key in address
+	legacyAddressLen int = 128 // hexa-decimal length of X and Y of legacy address
+
+	base58Alphabet string = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+//addressVersions is version byte of address for each network
+//networks not in it use version of testnet
+var addressVersions = map[string]byte{
+	"mainnet": 0x00,
+	"testnet": 0x6f,
+}
+
+var (
+	//ErrInvalidAddress is error returned when address is malformed or its checksum doesn't match
+	ErrInvalidAddress = errors.New("Address is invalid")
+	//ErrWrongNetwork is error returned when address belongs to other network
+	ErrWrongNetwork = errors.New("Address belongs to other network")
+)
+
+// networkVersion return version byte of address of network of node
+func networkVersion() byte {
+	if version, ok := addressVersions[config.Network()]; ok {
+		return version
+	}
+	return addressVersions["testnet"]
+}
+
+func checksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:checksumLen]
+}
+
+// encodeBase58 encode data with base58 alphabet, each leading zero byte becomes leading '1'
+func encodeBase58(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(int64(len(base58Alphabet)))
+	mod := new(big.Int)
+	var encoded []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
+
+// decodeBase58 decode string encoded by encodeBase58
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(int64(len(base58Alphabet)))
+	for _, c := range []byte(s) {
+		digit := bytes.IndexByte([]byte(base58Alphabet), c)
+		if digit < 0 {
+			return nil, ErrInvalidAddress
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// publicKeyHex return hexa-decimal compressed public key of point x, y
+func publicKeyHex(x, y *big.Int) string {
+	return hex.EncodeToString(elliptic.MarshalCompressed(elliptic.P256(), x, y))
+}
+
+//PublicKeyHash return hash of public key which address commits to, same as OP_SHA256 of it
+func PublicKeyHash(publicKey string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(publicKey)))
+}
+
+// encodeAddress return address of public key for network of version
+func encodeAddress(version byte, x, y *big.Int) string {
+	hash, _ := hex.DecodeString(PublicKeyHash(publicKeyHex(x, y)))
+	data := append([]byte{version}, hash...)
+	return encodeBase58(append(data, checksum(data)...))
+}
+
+// decodeAddress return version and payload of address after checking its checksum
+func decodeAddress(address string) (byte, []byte, error) {
+	data, err := decodeBase58(address)
+	if err != nil || len(data) <= 1+checksumLen {
+		return 0, nil, ErrInvalidAddress
+	}
+	payload, sum := data[:len(data)-checksumLen], data[len(data)-checksumLen:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return 0, nil, ErrInvalidAddress
+	}
+	return payload[0], payload[1:], nil
+}
+
+// publicKeyOf return version and public key of older address which carries public key itself
+// legacy address has no version, so version of network of node is returned for it
+func publicKeyOf(address string) (byte, *big.Int, *big.Int, error) {
+	if len(address) == legacyAddressLen {
+		if _, err := hex.DecodeString(address); err != nil {
+			return 0, nil, nil, ErrInvalidAddress
+		}
+		x, y, _ := restoreBigInts(address)
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return 0, nil, nil, ErrInvalidAddress
+		}
+		return networkVersion(), x, y, nil
+	}
+	version, payload, err := decodeAddress(address)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	x, y := elliptic.UnmarshalCompressed(elliptic.P256(), payload)
+	if x == nil {
+		return 0, nil, nil, ErrInvalidAddress
+	}
+	return version, x, y, nil
+}
+
+// parsePublicKeyItem return public key of item which OP_CHECKSIG pops
+// it is hexa-decimal compressed public key, or older address which carries public key itself
+func parsePublicKeyItem(item string) (*big.Int, *big.Int, error) {
+	if data, err := hex.DecodeString(item); err == nil && len(data) == 33 {
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), data)
+		if x == nil {
+			return nil, nil, ErrInvalidAddress
+		}
+		return x, y, nil
+	}
+	_, x, y, err := publicKeyOf(item)
+	return x, y, err
+}
+
+//AddressFromKey return address made by hash of public key(from input private key)
+func AddressFromKey(key *ecdsa.PrivateKey) string {
+	return encodeAddress(networkVersion(), key.X, key.Y)
+}
+
+//PublicKeyHashOf return hash of public key which address commits to
+func PublicKeyHashOf(address string) (string, error) {
+	if _, payload, err := decodeAddress(address); err == nil && len(payload) == hashLen {
+		return hex.EncodeToString(payload), nil
+	}
+	_, x, y, err := publicKeyOf(address)
+	if err != nil {
+		return "", err
+	}
+	return PublicKeyHash(publicKeyHex(x, y)), nil
+}
+
+//ValidateAddress return error if address is malformed, its checksum doesn't match or it belongs to other network
+//legacy address is not accepted because it has no checksum
+func ValidateAddress(address string) error {
+	version, payload, err := decodeAddress(address)
+	if err != nil {
+		return err
+	}
+	if len(payload) != hashLen {
+		if _, _, _, err := publicKeyOf(address); err != nil {
+			return err
+		}
+	}
+	if version != networkVersion() {
+		return ErrWrongNetwork
+	}
+	return nil
+}
+
+//NormalizeAddress return address of hash of public key for older address which carries public key itself
+//others are returned as they are
+//outputs paid to older address belong to owner of its public key, so they are compared after normalizing
+func NormalizeAddress(address string) string {
+	version, x, y, err := publicKeyOf(address)
+	if err != nil {
+		return address
+	}
+	return encodeAddress(version, x, y)
+}
@@ -0,0 +1,346 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/Gunyoung-Kim/blockchain/utils"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Keys of HD wallet are derived from BIP-39 mnemonic as SLIP-0010 describes for NIST P-256
+// receive addresses are derived along m/44'/0'/0'/0/i, public key of m/44'/0'/0'/0 is kept in keystore
+// so new receive addresses can be derived while wallet is locked
+const (
+	hdKind string = "hd" // kind of keystore of HD wallet
+
+	hardened     uint32 = 1 << 31
+	seedKey      string = "Nist256p1 seed"
+	entropyBits  int    = 256
+	receiveChain uint32 = 0
+
+	//GapLimit is number of unused addresses in a row after which rescan stops
+	GapLimit int = 20
+)
+
+var accountPath = []uint32{44 + hardened, 0 + hardened, 0 + hardened}
+
+var (
+	//ErrNotHD is error returned when single key wallet is asked for operation of HD wallet
+	ErrNotHD = errors.New("Wallet is not HD wallet")
+	//ErrInvalidMnemonic is error returned when mnemonic has unknown word or wrong checksum
+	ErrInvalidMnemonic = errors.New("Mnemonic is invalid")
+	//ErrUnknownAddress is error returned when wallet doesn't have key of address
+	ErrUnknownAddress = errors.New("Address doesn't belong to wallet")
+
+	errHardenedFromPublic = errors.New("Hardened child can't be derived from public key")
+)
+
+// extendedKey is key with chain code which derives child keys
+// private is nil for public extended key
+type extendedKey struct {
+	private   *big.Int
+	x, y      *big.Int
+	chainCode []byte
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func ser32(i uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, i)
+	return b
+}
+
+// masterKey derive master extended key from seed
+func masterKey(seed []byte) *extendedKey {
+	curve := elliptic.P256()
+	I := hmacSHA512([]byte(seedKey), seed)
+	for {
+		il := new(big.Int).SetBytes(I[:32])
+		if il.Sign() != 0 && il.Cmp(curve.Params().N) < 0 {
+			x, y := curve.ScalarBaseMult(I[:32])
+			return &extendedKey{il, x, y, I[32:]}
+		}
+		I = hmacSHA512([]byte(seedKey), I)
+	}
+}
+
+// child derive child extended key at index i, index from hardened is hardened child
+func (k *extendedKey) child(i uint32) (*extendedKey, error) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	var data []byte
+	if i >= hardened {
+		if k.private == nil {
+			return nil, errHardenedFromPublic
+		}
+		data = append([]byte{0}, k.private.FillBytes(make([]byte, 32))...)
+	} else {
+		data = elliptic.MarshalCompressed(curve, k.x, k.y)
+	}
+	data = append(data, ser32(i)...)
+
+	for {
+		I := hmacSHA512(k.chainCode, data)
+		il := new(big.Int).SetBytes(I[:32])
+		if il.Cmp(n) < 0 {
+			if k.private != nil {
+				private := il.Add(il, k.private)
+				private.Mod(private, n)
+				if private.Sign() != 0 {
+					x, y := curve.ScalarBaseMult(private.FillBytes(make([]byte, 32)))
+					return &extendedKey{private, x, y, I[32:]}, nil
+				}
+			} else {
+				x, y := curve.ScalarBaseMult(I[:32])
+				x, y = curve.Add(x, y, k.x, k.y)
+				if x.Sign() != 0 || y.Sign() != 0 {
+					return &extendedKey{nil, x, y, I[32:]}, nil
+				}
+			}
+		}
+		data = append(append([]byte{1}, I[32:]...), ser32(i)...)
+	}
+}
+
+// derive derive descendant extended key along path
+func (k *extendedKey) derive(path []uint32) (*extendedKey, error) {
+	key := k
+	for _, i := range path {
+		child, err := key.child(i)
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	return key, nil
+}
+
+// public return public extended key of k
+func (k *extendedKey) public() *extendedKey {
+	return &extendedKey{nil, k.x, k.y, k.chainCode}
+}
+
+func (k *extendedKey) privateKey() *ecdsa.PrivateKey {
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: k.x, Y: k.y},
+		D:         k.private,
+	}
+}
+
+func (k *extendedKey) address() string {
+	return encodeAddress(networkVersion(), k.x, k.y)
+}
+
+// String encode public extended key as hexa-decimal compressed public key followed by chain code
+func (k *extendedKey) String() string {
+	return hex.EncodeToString(append(elliptic.MarshalCompressed(elliptic.P256(), k.x, k.y), k.chainCode...))
+}
+
+// parsePublicKey decode public extended key encoded by String
+func parsePublicKey(s string) (*extendedKey, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) != 33+32 {
+		return nil, ErrInvalidWallet
+	}
+	x, y := elliptic.UnmarshalCompressed(elliptic.P256(), data[:33])
+	if x == nil {
+		return nil, ErrInvalidWallet
+	}
+	return &extendedKey{nil, x, y, data[33:]}, nil
+}
+
+// receiveKey derive extended key of receive chain from mnemonic
+func receiveKey(mnemonic string) (*extendedKey, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+	return masterKey(bip39.NewSeed(mnemonic, "")).derive(append(accountPath, receiveChain))
+}
+
+// newMnemonic return random mnemonic
+func newMnemonic() string {
+	entropy, err := bip39.NewEntropy(entropyBits)
+	utils.HandleError(err)
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	utils.HandleError(err)
+	return mnemonic
+}
+
+// encryptMnemonic encrypt mnemonic with passphrase into keystore of HD wallet
+// first receive address becomes address of wallet
+func encryptMnemonic(mnemonic, passphrase string) (*keystore, error) {
+	receive, err := receiveKey(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	first, err := receive.child(0)
+	if err != nil {
+		return nil, err
+	}
+	ks := &keystore{
+		Address:    first.address(),
+		Kind:       hdKind,
+		ReceiveKey: receive.public().String(),
+		Addresses:  []string{first.address()},
+	}
+	if err := ks.seal([]byte(mnemonic), passphrase); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// receiveAddress derive receive address at index from public key of receive chain in keystore
+func (ks *keystore) receiveAddress(index int) (string, error) {
+	receive, err := parsePublicKey(ks.ReceiveKey)
+	if err != nil {
+		return "", err
+	}
+	child, err := receive.child(uint32(index))
+	if err != nil {
+		return "", err
+	}
+	return child.address(), nil
+}
+
+//HD return whether wallet derives its keys from mnemonic
+func (w *wallet) HD() bool {
+	return w.keystore.Kind == hdKind
+}
+
+//Addresses return all addresses of wallet, first one is Address
+func (w *wallet) Addresses() []string {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if !w.HD() {
+		return []string{w.Address}
+	}
+	return w.addresses()
+}
+
+// addresses return addresses in keystore of HD wallet, legacy ones are normalized
+func (w *wallet) addresses() []string {
+	var addresses []string
+	for _, address := range w.keystore.Addresses {
+		addresses = append(addresses, NormalizeAddress(address))
+	}
+	return addresses
+}
+
+//NewAddress derive next receive address of HD wallet and remember it in wallet file
+//it works while wallet is locked because receive addresses are derived from public key
+func (w *wallet) NewAddress() (string, error) {
+	if !w.HD() {
+		return "", ErrNotHD
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	address, err := w.keystore.receiveAddress(len(w.keystore.Addresses))
+	if err != nil {
+		return "", err
+	}
+	w.keystore.Addresses = append(w.keystore.Addresses, address)
+	if err := persistKeystore(walletPath(w.Name), w.keystore); err != nil {
+		w.keystore.Addresses = w.keystore.Addresses[:len(w.keystore.Addresses)-1]
+		return "", err
+	}
+	return address, nil
+}
+
+//Rescan derive receive addresses from index 0 until GapLimit addresses in a row are not used
+//all addresses up to last used one are remembered, addresses already handed out are kept
+//it returns all addresses of wallet
+func (w *wallet) Rescan(used func(address string) bool) ([]string, error) {
+	if !w.HD() {
+		return nil, ErrNotHD
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	var addresses []string
+	lastUsed := -1
+	for index := 0; index-lastUsed <= GapLimit; index++ {
+		address, err := w.keystore.receiveAddress(index)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+		if used(address) {
+			lastUsed = index
+		}
+	}
+	if lastUsed+1 > len(w.keystore.Addresses) {
+		w.keystore.Addresses = addresses[:lastUsed+1]
+		if err := persistKeystore(walletPath(w.Name), w.keystore); err != nil {
+			return nil, err
+		}
+	}
+	return w.addresses(), nil
+}
+
+//Mnemonic return mnemonic of HD wallet if passphrase is correct
+func (w *wallet) Mnemonic(passphrase string) (string, error) {
+	if !w.HD() {
+		return "", ErrNotHD
+	}
+	plain, err := w.keystore.open(passphrase)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+//Restore make new HD wallet of name from mnemonic, encrypt it with passphrase and load it unlocked
+//used addresses are restored by Rescan with used
+func Restore(name, passphrase, mnemonic string, used func(address string) bool) (*wallet, error) {
+	if err := checkNewName(name); err != nil {
+		return nil, err
+	}
+	ks, err := encryptMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	restored, err := createWallet(name, passphrase, ks)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := restored.Rescan(used); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// keyOf return private key of address which belongs to wallet
+func (w *wallet) keyOf(address string) (*ecdsa.PrivateKey, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.privateKey == nil {
+		return nil, ErrLocked
+	}
+	address = NormalizeAddress(address)
+	if address == w.Address {
+		return w.privateKey, nil
+	}
+	if w.receive != nil {
+		for index, known := range w.addresses() {
+			if known == address {
+				child, err := w.receive.child(uint32(index))
+				if err != nil {
+					return nil, err
+				}
+				return child.privateKey(), nil
+			}
+		}
+	}
+	return nil, ErrUnknownAddress
+}
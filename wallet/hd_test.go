@@ -0,0 +1,74 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// SLIP-0010 test vector 1 for nist256p1
+// https://github.com/satoshilabs/slips/blob/master/slip-0010.md#test-vector-1-for-nist256p1
+var slip10Vectors = []struct {
+	path       string
+	derivation []uint32
+	chainCode  string
+	private    string
+	public     string
+}{
+	{
+		"m", nil,
+		"beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea",
+		"612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2",
+		"0266874dc6ade47b3ecd096745ca09bcd29638dd52c2c12117b11ed3e458cfa9e8",
+	},
+	{
+		"m/0H", []uint32{0 + hardened},
+		"3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11",
+		"6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c",
+		"0384610f5ecffe8fda089363a41f56a5c7ffc1d81b59a612d0d649b2d22355590c",
+	},
+}
+
+func TestSLIP10Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master := masterKey(seed)
+	for _, vector := range slip10Vectors {
+		key, err := master.derive(vector.derivation)
+		if err != nil {
+			t.Fatalf("%s: %v", vector.path, err)
+		}
+		encoded := key.String() // compressed public key followed by chain code
+		if chainCode := hex.EncodeToString(key.chainCode); chainCode != vector.chainCode {
+			t.Errorf("%s: chain code %s, want %s", vector.path, chainCode, vector.chainCode)
+		}
+		if private := hex.EncodeToString(key.private.FillBytes(make([]byte, 32))); private != vector.private {
+			t.Errorf("%s: private key %s, want %s", vector.path, private, vector.private)
+		}
+		if public := encoded[:66]; public != vector.public {
+			t.Errorf("%s: public key %s, want %s", vector.path, public, vector.public)
+		}
+	}
+}
+
+func TestPublicDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	account, err := masterKey(seed).derive(accountPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// receive addresses are derived from public key while wallet is locked, they must match private derivation
+	fromPrivate, err := account.derive([]uint32{receiveChain, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromPublic, err := account.public().derive([]uint32{receiveChain, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromPublic.String() != fromPrivate.public().String() {
+		t.Errorf("public derivation %s, want %s", fromPublic, fromPrivate.public())
+	}
+
+	if _, err := account.public().child(hardened); err != errHardenedFromPublic {
+		t.Errorf("hardened child of public key = %v, want %v", err, errHardenedFromPublic)
+	}
+}
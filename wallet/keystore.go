@@ -0,0 +1,189 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/Gunyoung-Kim/blockchain/config"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keystoreKDF string = "scrypt"
+
+	// parameters of scrypt which derives key for AES-256-GCM from passphrase
+	scryptN      int = 1 << 15
+	scryptR      int = 8
+	scryptP      int = 1
+	scryptKeyLen int = 32
+	saltLen      int = 32
+)
+
+var (
+	//ErrWrongPassphrase is error returned when passphrase can't decrypt wallet file
+	ErrWrongPassphrase = errors.New("Wrong passphrase")
+	//ErrNoPassphrase is error returned when wallet file needs to be encrypted but there is no passphrase
+	ErrNoPassphrase = errors.New("Passphrase is needed to encrypt wallet file")
+	//ErrInvalidWallet is error returned when wallet file is neither keystore nor private key
+	ErrInvalidWallet = errors.New("Wallet file is broken")
+)
+
+// keystore is content of wallet file
+// secret is encrypted with AES-256-GCM whose key is derived from passphrase by scrypt
+// secret is private key of single key wallet or mnemonic of HD wallet
+// addresses are kept in plain text, so wallet can receive coins while it is locked
+// labels and watch-only addresses are kept in plain text too, they are not secret
+type keystore struct {
+	Address    string   `json:"address"`
+	Kind       string   `json:"kind,omitempty"`
+	ReceiveKey string   `json:"receiveKey,omitempty"`
+	Addresses  []string `json:"addresses,omitempty"`
+	Labels     *Labels  `json:"labels,omitempty"`
+	Watch      []string `json:"watch,omitempty"`
+	KDF        string   `json:"kdf"`
+	N          int      `json:"n"`
+	R          int      `json:"r"`
+	P          int      `json:"p"`
+	Salt       string   `json:"salt"`
+	Nonce      string   `json:"nonce"`
+	Ciphertext string   `json:"ciphertext"`
+}
+
+// newGCM make AES-GCM cipher with key derived from passphrase and salt
+func newGCM(passphrase string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptKey encrypt private key with passphrase into keystore
+func encryptKey(key *ecdsa.PrivateKey, passphrase string) (*keystore, error) {
+	plain, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	ks := &keystore{Address: AddressFromKey(key)}
+	if err := ks.seal(plain, passphrase); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// decryptKey decrypt private key in keystore with passphrase
+func decryptKey(ks *keystore, passphrase string) (*ecdsa.PrivateKey, error) {
+	plain, err := ks.open(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParseECPrivateKey(plain)
+	if err != nil {
+		return nil, ErrInvalidWallet
+	}
+	return key, nil
+}
+
+// seal encrypt secret with passphrase into keystore
+// address is authenticated together, so it can't be replaced without passphrase
+func (ks *keystore) seal(plain []byte, passphrase string) error {
+	if passphrase == "" {
+		return ErrNoPassphrase
+	}
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(passphrase, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	ks.KDF = keystoreKDF
+	ks.N, ks.R, ks.P = scryptN, scryptR, scryptP
+	ks.Salt = hex.EncodeToString(salt)
+	ks.Nonce = hex.EncodeToString(nonce)
+	ks.Ciphertext = hex.EncodeToString(gcm.Seal(nil, nonce, plain, []byte(ks.Address)))
+	return nil
+}
+
+// open decrypt secret in keystore with passphrase
+func (ks *keystore) open(passphrase string) ([]byte, error) {
+	salt, err := hex.DecodeString(ks.Salt)
+	if err != nil || ks.KDF != keystoreKDF {
+		return nil, ErrInvalidWallet
+	}
+	nonce, err := hex.DecodeString(ks.Nonce)
+	if err != nil {
+		return nil, ErrInvalidWallet
+	}
+	ciphertext, err := hex.DecodeString(ks.Ciphertext)
+	if err != nil {
+		return nil, ErrInvalidWallet
+	}
+	gcm, err := newGCM(passphrase, salt, ks.N, ks.R, ks.P)
+	if err != nil {
+		return nil, ErrInvalidWallet
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, ErrInvalidWallet
+	}
+	plain, err := gcm.Open(nil, nonce, ciphertext, []byte(ks.Address))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plain, nil
+}
+
+// persistKeystore write keystore into wallet file of path readable only by owner
+// permission is set again because WriteFile keeps permission of existing file
+// nothing is written for node keeping its data in memory
+func persistKeystore(path string, ks *keystore) error {
+	if config.Memory() {
+		return nil
+	}
+	data, err := json.Marshal(ks)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+// restoreKeystore read keystore from wallet file of path
+// wallet file written before encryption is raw x509 private key, it is returned as key instead of keystore
+// there is no wallet file for node keeping its data in memory
+func restoreKeystore(path string) (*keystore, *ecdsa.PrivateKey, error) {
+	if config.Memory() {
+		return nil, nil, os.ErrNotExist
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	ks := &keystore{}
+	if json.Unmarshal(data, ks) == nil {
+		return ks, nil, nil
+	}
+	key, err := x509.ParseECPrivateKey(data)
+	if err != nil {
+		return nil, nil, ErrInvalidWallet
+	}
+	return nil, key, nil
+}
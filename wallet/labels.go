@@ -0,0 +1,72 @@
+package wallet
+
+import "errors"
+
+//ErrNoLabelTarget is error returned when label is given for neither or both of transaction and address
+var ErrNoLabelTarget = errors.New("Label needs ID of transaction or address")
+
+//Labels is notes which user attaches to transactions and addresses, keyed by ID of transaction and address
+type Labels struct {
+	Txs       map[string]string `json:"txs,omitempty"`
+	Addresses map[string]string `json:"addresses,omitempty"`
+}
+
+//Labels return copy of labels of wallet
+func (w *wallet) Labels() *Labels {
+	w.m.Lock()
+	defer w.m.Unlock()
+	labels := &Labels{Txs: map[string]string{}, Addresses: map[string]string{}}
+	if w.keystore.Labels == nil {
+		return labels
+	}
+	for txID, label := range w.keystore.Labels.Txs {
+		labels.Txs[txID] = label
+	}
+	for address, label := range w.keystore.Labels.Addresses {
+		labels.Addresses[address] = label
+	}
+	return labels
+}
+
+//SetTxLabel attach label to transaction of ID and remember it in wallet file, empty label removes it
+func (w *wallet) SetTxLabel(txID, label string) error {
+	return w.setLabel(func(labels *Labels) *map[string]string { return &labels.Txs }, txID, label)
+}
+
+//SetAddressLabel attach label to address and remember it in wallet file, empty label removes it
+//address doesn't have to belong to wallet, so senders and receivers can be labeled too
+func (w *wallet) SetAddressLabel(address, label string) error {
+	if err := ValidateAddress(address); err != nil {
+		return err
+	}
+	return w.setLabel(func(labels *Labels) *map[string]string { return &labels.Addresses }, NormalizeAddress(address), label)
+}
+
+// setLabel set label of key in map of labels which field chooses, then persist keystore
+// labels are restored as they were if wallet file can't be written
+func (w *wallet) setLabel(field func(labels *Labels) *map[string]string, key, label string) error {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.keystore.Labels == nil {
+		w.keystore.Labels = &Labels{}
+	}
+	labels := field(w.keystore.Labels)
+	if *labels == nil {
+		*labels = make(map[string]string)
+	}
+	previous, existed := (*labels)[key]
+	if label == "" {
+		delete(*labels, key)
+	} else {
+		(*labels)[key] = label
+	}
+	if err := persistKeystore(walletPath(w.Name), w.keystore); err != nil {
+		if existed {
+			(*labels)[key] = previous
+		} else {
+			delete(*labels, key)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package wallet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Gunyoung-Kim/blockchain/config"
+)
+
+const (
+	//DefaultName is name of wallet in walletFileName, which node uses unless other wallet is chosen
+	DefaultName string = "default"
+
+	walletsDirName string = "wallets" // directory of named wallets in data directory
+	walletExt      string = ".wallet"
+)
+
+var (
+	//ErrWalletExists is error returned when wallet is created with name which is already used
+	ErrWalletExists = errors.New("Wallet already exists")
+	//ErrWalletNotFound is error returned when there is no wallet of name or it is not loaded
+	ErrWalletNotFound = errors.New("Wallet is not found")
+	//ErrInvalidName is error returned when name of wallet can't be used for file name
+	ErrInvalidName = errors.New("Name of wallet must consist of letters, digits, '-' and '_'")
+	//ErrNoOwner is error returned when no loaded wallet has key of public key hash
+	ErrNoOwner = errors.New("No loaded wallet has key of public key hash")
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// wallets is wallets loaded on node by name
+// Protected by mutex from data races
+type wallets struct {
+	v map[string]*wallet
+	m sync.Mutex
+}
+
+var loaded = wallets{
+	v: make(map[string]*wallet),
+}
+
+func (l *wallets) add(w *wallet) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.v[w.Name] = w
+}
+
+// names return names of loaded wallets except default wallet
+func (l *wallets) names() []string {
+	l.m.Lock()
+	defer l.m.Unlock()
+	var names []string
+	for name := range l.v {
+		if name != DefaultName {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (l *wallets) get(name string) (*wallet, bool) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	w, ok := l.v[name]
+	return w, ok
+}
+
+//Info is summary of wallet for listing
+type Info struct {
+	Name      string   `json:"name"`
+	Address   string   `json:"address"`
+	Addresses []string `json:"addresses"`
+	HD        bool     `json:"hd"`
+	Loaded    bool     `json:"loaded"`
+	Locked    bool     `json:"locked"`
+}
+
+// walletPath return path of wallet file of name
+// default wallet lives in walletFileName, others in walletsDirName
+func walletPath(name string) string {
+	if name == DefaultName {
+		return config.Path(walletFileName)
+	}
+	return filepath.Join(config.Path(walletsDirName), name+walletExt)
+}
+
+//Create make new HD wallet of name encrypted with passphrase and load it unlocked
+//its mnemonic is given by Mnemonic
+func Create(name, passphrase string) (*wallet, error) {
+	if err := checkNewName(name); err != nil {
+		return nil, err
+	}
+	ks, err := encryptMnemonic(newMnemonic(), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return createWallet(name, passphrase, ks)
+}
+
+// checkNewName check name can be used for new wallet
+func checkNewName(name string) error {
+	if !validName.MatchString(name) {
+		return ErrInvalidName
+	}
+	if _, ok := loaded.get(name); ok {
+		return ErrWalletExists
+	}
+	if _, err := os.Stat(walletPath(name)); err == nil && !config.Memory() {
+		return ErrWalletExists
+	}
+	return nil
+}
+
+// createWallet write keystore into wallet file of name and load it unlocked
+func createWallet(name, passphrase string, ks *keystore) (*wallet, error) {
+	if !config.Memory() {
+		if err := os.MkdirAll(config.Path(walletsDirName), 0700); err != nil {
+			return nil, err
+		}
+	}
+	if err := persistKeystore(walletPath(name), ks); err != nil {
+		return nil, err
+	}
+	created := &wallet{Name: name, Address: ks.Address, keystore: ks}
+	if err := created.Unlock(passphrase, 0); err != nil {
+		return nil, err
+	}
+	loaded.add(created)
+	return created, nil
+}
+
+//Load load wallet of name from its wallet file
+//wallet is unlocked until Lock if passphrase is given, otherwise it is locked
+//wallet which is already loaded is returned as it is after unlocking
+func Load(name, passphrase string) (*wallet, error) {
+	if !validName.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	if w, ok := loaded.get(name); ok {
+		if passphrase != "" {
+			if err := w.Unlock(passphrase, 0); err != nil {
+				return nil, err
+			}
+		}
+		return w, nil
+	}
+	opened, err := openWallet(name, passphrase, false)
+	if err != nil {
+		return nil, err
+	}
+	loaded.add(opened)
+	return opened, nil
+}
+
+//OpenFile open wallet file of path unlocked with passphrase without loading it on node
+//it is used for signing transaction out of node, wallet file of raw private key needs no passphrase
+func OpenFile(path, passphrase string) (*wallet, error) {
+	ks, key, err := restoreKeystore(path)
+	if err != nil {
+		return nil, err
+	}
+	if ks == nil {
+		address := AddressFromKey(key)
+		return &wallet{privateKey: key, Address: address, keystore: &keystore{Address: address}}, nil
+	}
+	opened := &wallet{Name: strings.TrimSuffix(filepath.Base(path), walletExt), Address: NormalizeAddress(ks.Address), keystore: ks}
+	if err := opened.Unlock(passphrase, 0); err != nil {
+		return nil, err
+	}
+	return opened, nil
+}
+
+//Info return summary of loaded wallet
+func (w *wallet) Info() *Info {
+	return &Info{
+		Name:      w.Name,
+		Address:   w.Address,
+		Addresses: w.Addresses(),
+		HD:        w.HD(),
+		Loaded:    true,
+		Locked:    w.Locked(),
+	}
+}
+
+//Get return loaded wallet of name, empty name means default wallet
+//default wallet is not found until Open is called
+func Get(name string) (*wallet, error) {
+	if name == "" || name == DefaultName {
+		if Wallet() == nil {
+			return nil, ErrWalletNotFound
+		}
+		return Wallet(), nil
+	}
+	w, ok := loaded.get(name)
+	if !ok {
+		return nil, ErrWalletNotFound
+	}
+	return w, nil
+}
+
+//Owner return loaded wallet and its address whose public key hash is hash
+//default wallet is searched first, then others in order of name
+func Owner(hash string) (*wallet, string, error) {
+	names := loaded.names()
+	sort.Strings(names)
+	for _, name := range append([]string{DefaultName}, names...) {
+		w, err := Get(name)
+		if err != nil {
+			continue
+		}
+		for _, address := range w.Addresses() {
+			if owned, err := PublicKeyHashOf(address); err == nil && owned == hash {
+				return w, address, nil
+			}
+		}
+	}
+	return nil, "", ErrNoOwner
+}
+
+//List return all wallets in data directory ordered by name, with default wallet first
+//node keeping its data in memory lists wallets it has loaded
+func List() []*Info {
+	names := []string{}
+	if config.Memory() {
+		names = loaded.names()
+	} else {
+		files, _ := os.ReadDir(config.Path(walletsDirName))
+		for _, file := range files {
+			if !file.IsDir() && strings.HasSuffix(file.Name(), walletExt) {
+				names = append(names, strings.TrimSuffix(file.Name(), walletExt))
+			}
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{DefaultName}, names...)
+
+	var infos []*Info
+	for _, name := range names {
+		if w, err := Get(name); err == nil {
+			infos = append(infos, w.Info())
+		} else if ks, _, err := restoreKeystore(walletPath(name)); err == nil && ks != nil {
+			unloaded := &wallet{Name: name, Address: NormalizeAddress(ks.Address), keystore: ks}
+			info := unloaded.Info()
+			info.Loaded = false
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
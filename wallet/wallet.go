@@ -4,12 +4,15 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/x509"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/Gunyoung-Kim/blockchain/config"
 	"github.com/Gunyoung-Kim/blockchain/utils"
 )
 
@@ -18,66 +21,170 @@ const (
 )
 
 type wallet struct {
-	privateKey *ecdsa.PrivateKey
+	privateKey *ecdsa.PrivateKey // nil while wallet is locked
+	receive    *extendedKey      // private key of receive chain of HD wallet, nil while wallet is locked
+	Name       string
 	Address    string
+	keystore   *keystore
+	lockTimer  *time.Timer
+	m          sync.Mutex
 }
 
 var w *wallet
 
-// hasWalletFile return whether there is walletFile or not
-func hasWalletFile() bool {
-	_, err := os.Stat(walletFileName)
-	return !os.IsNotExist(err)
+//ErrLocked is error returned when wallet is asked to sign while it is locked
+var ErrLocked = errors.New("Wallet is locked")
+
+//Open load default wallet from wallet file, it must be called before Wallet is used
+//if there is no wallet file, new HD wallet is created and encrypted with passphrase
+//wallet file of raw private key written by older version is encrypted with passphrase
+//wallet stays unlocked until Lock if passphrase is given, otherwise it is locked
+//node keeping its data in memory always creates new HD wallet, which is unlocked even without passphrase
+func Open(passphrase string) error {
+	opened, err := openWallet(DefaultName, passphrase, true)
+	if err != nil {
+		return err
+	}
+	w = opened
+	loaded.add(w)
+	return nil
 }
 
-// createPrivateKey create new {@code ecdsa.PrivateKey} then return it
-func createPrivateKey() *ecdsa.PrivateKey {
-	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	utils.HandleError(err)
-	return privateKey
+// openWallet load wallet of name from its wallet file
+// new HD wallet is created if there is no wallet file and create is true
+func openWallet(name, passphrase string, create bool) (*wallet, error) {
+	path := walletPath(name)
+	ks, key, err := restoreKeystore(path)
+	if os.IsNotExist(err) {
+		if !create {
+			return nil, ErrWalletNotFound
+		}
+		if passphrase == "" && config.Memory() {
+			// nobody could unlock wallet which lives only as long as node, so it is kept unlocked with random passphrase
+			passphrase = NewPreimage()
+		}
+		ks, err = encryptMnemonic(newMnemonic(), passphrase)
+		if err == nil {
+			err = persistKeystore(path, ks)
+		}
+	} else if err == nil && ks == nil {
+		// wallet file of raw private key written before encryption
+		ks, err = encryptKey(key, passphrase)
+		if err == nil {
+			err = persistKeystore(path, ks)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	opened := &wallet{Name: name, Address: NormalizeAddress(ks.Address), keystore: ks}
+	if passphrase != "" {
+		if err := opened.Unlock(passphrase, 0); err != nil {
+			return nil, err
+		}
+	}
+	return opened, nil
 }
 
-// persistKey persist privateKey into file
-// turn {@code ecdsa.PrivateKey} into slice of bytes
-func persistKey(key *ecdsa.PrivateKey) {
-	bytes, err := x509.MarshalECPrivateKey(key)
-	utils.HandleError(err)
-	utils.HandleError(os.WriteFile(walletFileName, bytes, 0644)) // read and write
+//Unlock decrypt private key of wallet with passphrase so wallet can sign
+//keys of HD wallet are derived from its mnemonic
+//wallet is locked again after timeout, zero timeout keeps it unlocked until Lock
+func (w *wallet) Unlock(passphrase string, timeout time.Duration) error {
+	var key *ecdsa.PrivateKey
+	var receive *extendedKey
+	if w.HD() {
+		mnemonic, err := w.Mnemonic(passphrase)
+		if err != nil {
+			return err
+		}
+		if receive, err = receiveKey(mnemonic); err != nil {
+			return err
+		}
+		first, err := receive.child(0)
+		if err != nil {
+			return err
+		}
+		key = first.privateKey()
+	} else {
+		var err error
+		if key, err = decryptKey(w.keystore, passphrase); err != nil {
+			return err
+		}
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.privateKey = key
+	w.receive = receive
+	if w.lockTimer != nil {
+		w.lockTimer.Stop()
+		w.lockTimer = nil
+	}
+	if timeout > 0 {
+		w.lockTimer = time.AfterFunc(timeout, w.Lock)
+	}
+	return nil
 }
 
-// restoreKey restore privateKey from walletFile
-// turn slice of bytes into {@code *ecdsa.PrivateKey}
-func restoreKey() (key *ecdsa.PrivateKey) {
-	keyAsBytes, err := os.ReadFile(walletFileName)
-	utils.HandleError(err)
-	key, err = x509.ParseECPrivateKey(keyAsBytes)
-	utils.HandleError(err)
-	return
+//Lock forget decrypted private key of wallet
+func (w *wallet) Lock() {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.privateKey = nil
+	w.receive = nil
+	if w.lockTimer != nil {
+		w.lockTimer.Stop()
+		w.lockTimer = nil
+	}
+}
+
+//Locked return whether wallet can't sign
+func (w *wallet) Locked() bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.privateKey == nil
 }
 
 // encodeBigInts return hexa-decimal string made from two big int
+// each one is padded to 32 bytes, so restoreBigInts can split them in half
 func encodeBigInts(a, b *big.Int) string {
-	c := append(a.Bytes(), b.Bytes()...)
+	c := append(a.FillBytes(make([]byte, 32)), b.FillBytes(make([]byte, 32))...)
 	return fmt.Sprintf("%x", c)
 }
 
-//AddressFromKey return address(hex) made by public key(from input private key)
-func AddressFromKey(key *ecdsa.PrivateKey) string {
-	return encodeBigInts(key.X, key.Y)
-}
-
 //Sign return hexa-decimal string made by r, s
 // r, s made by input privatekey(from wallet) and payload(transaction id)
-func Sign(payload string, w *wallet) string {
+//it returns ErrLocked if wallet is locked
+func Sign(payload string, w *wallet) (string, error) {
+	return w.SignAs(payload, w.Address)
+}
+
+//SignAs sign payload like Sign with private key of address which belongs to wallet
+//it returns ErrUnknownAddress if address doesn't belong to wallet
+func (w *wallet) SignAs(payload, address string) (string, error) {
+	key, err := w.keyOf(address)
+	if err != nil {
+		return "", err
+	}
 	payloadAsBytes, err := hex.DecodeString(payload)
 	utils.HandleError(err)
-	r, s, err := ecdsa.Sign(rand.Reader, w.privateKey, payloadAsBytes)
+	r, s, err := ecdsa.Sign(rand.Reader, key, payloadAsBytes)
 	utils.HandleError(err)
-	return encodeBigInts(r, s)
+	return encodeBigInts(r, s), nil
+}
+
+//PublicKeyAs return hexa-decimal compressed public key of address which belongs to wallet
+//it is revealed with signature when output paid to hash of public key is spent
+func (w *wallet) PublicKeyAs(address string) (string, error) {
+	key, err := w.keyOf(address)
+	if err != nil {
+		return "", err
+	}
+	return publicKeyHex(key.X, key.Y), nil
 }
 
 // restoreBigInts turn hexa-decimal string into two {@code big.int}
-// this method is used for turning signature into r, s and turning address into x,y(used for making public key)
+// this method is used for turning signature into r, s and turning legacy address into x,y(used for making public key)
 func restoreBigInts(payload string) (*big.Int, *big.Int, error) {
 	bytes, err := hex.DecodeString(payload)
 	if err != nil {
@@ -92,36 +199,40 @@ func restoreBigInts(payload string) (*big.Int, *big.Int, error) {
 }
 
 //Verify input signature is correct with transaction id and public key.
-//public key is made by input address
-func Verify(signature, payload, address string) bool {
+//public key is hexa-decimal compressed public key or older address which carries public key itself
+//malformed signature or public key is not correct, so it returns false for them
+func Verify(signature, payload, publicKey string) bool {
 	r, s, err := restoreBigInts(signature)
-	utils.HandleError(err)
-	x, y, err := restoreBigInts(address)
-	utils.HandleError(err)
-	publicKey := ecdsa.PublicKey{
+	if err != nil {
+		return false
+	}
+	x, y, err := parsePublicKeyItem(publicKey)
+	if err != nil {
+		return false
+	}
+	key := ecdsa.PublicKey{
 		Curve: elliptic.P256(),
 		X:     x,
 		Y:     y,
 	}
 	payloadBytes, err := hex.DecodeString(payload)
-	utils.HandleError(err)
-	ok := ecdsa.Verify(&publicKey, payloadBytes, r, s)
+	if err != nil {
+		return false
+	}
+	ok := ecdsa.Verify(&key, payloadBytes, r, s)
 	return ok
 }
 
-// Wallet return pointer of wallet which is made by singleton pattern
+// Wallet return pointer of default wallet which is opened by Open
+// it is nil until Open is called, Get returns ErrWalletNotFound instead
 func Wallet() *wallet {
-	if w == nil {
-		w = &wallet{}
-		if hasWalletFile() {
-			w.privateKey = restoreKey()
-		} else {
-			key := createPrivateKey()
-			persistKey(key)
-			w.privateKey = key
-		}
-		w.Address = AddressFromKey(w.privateKey)
-	}
-
 	return w
 }
+
+//NewPreimage return random secret(hex) which is used for hash lock
+func NewPreimage() string {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	utils.HandleError(err)
+	return hex.EncodeToString(secret)
+}
@@ -0,0 +1,18 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetBeforeOpen(t *testing.T) {
+	// command which runs before node opens wallet gets error instead of creating wallet without passphrase
+	if Wallet() != nil {
+		t.Fatal("default wallet is opened before Open")
+	}
+	for _, name := range []string{"", DefaultName} {
+		if _, err := Get(name); !errors.Is(err, ErrWalletNotFound) {
+			t.Errorf("Get(%q) before Open = %v, want %v", name, err, ErrWalletNotFound)
+		}
+	}
+}
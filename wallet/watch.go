@@ -0,0 +1,56 @@
+package wallet
+
+//Watch register addresses as watch-only and remember them in wallet file
+//wallet has no keys of them, so their funds are only monitored and never spent
+//addresses are validated before any of them is registered, ones already watched are ignored
+func (w *wallet) Watch(addresses ...string) error {
+	var normalized []string
+	for _, address := range addresses {
+		if err := ValidateAddress(address); err != nil {
+			return err
+		}
+		normalized = append(normalized, NormalizeAddress(address))
+	}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+	previous := w.keystore.Watch
+	watched := make(map[string]bool)
+	for _, address := range previous {
+		watched[address] = true
+	}
+	for _, address := range normalized {
+		if !watched[address] {
+			watched[address] = true
+			w.keystore.Watch = append(w.keystore.Watch, address)
+		}
+	}
+	if len(w.keystore.Watch) == len(previous) {
+		return nil
+	}
+	if err := persistKeystore(walletPath(w.Name), w.keystore); err != nil {
+		w.keystore.Watch = previous
+		return err
+	}
+	return nil
+}
+
+//Watched return watch-only addresses of wallet
+func (w *wallet) Watched() []string {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return append([]string{}, w.keystore.Watch...)
+}
+
+//WatchedAddresses return set of watch-only addresses of all loaded wallets
+func WatchedAddresses() map[string]bool {
+	loaded.m.Lock()
+	defer loaded.m.Unlock()
+	watched := make(map[string]bool)
+	for _, w := range loaded.v {
+		for _, address := range w.Watched() {
+			watched[address] = true
+		}
+	}
+	return watched
+}